@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// adminSigner returns the admin signer from --admin-signer, --admin-keystore,
+// --admin-key-env or the profile's admin key, the devnet admin key is only
+// used with --dev. It returns nil when no source is given, so commands not
+// sending admin transactions still work.
+func adminSigner(ctx *cli.Context, chain *repo.ChainConfig) (utils.Signer, error) {
+	var sources []string
+	for _, name := range []string{"admin-signer", "admin-keystore", "admin-key-env"} {
+		if ctx.String(name) != "" {
+			sources = append(sources, "--"+name)
+		}
+	}
+	if len(sources) > 1 {
+		return nil, fmt.Errorf("admin key sources %v are exclusive", sources)
+	}
+
+	switch {
+	case ctx.String("admin-signer") != "":
+		return utils.NewExternalSigner(ctx.String("admin-signer"), ctx.String("admin-address"))
+	case ctx.String("admin-keystore") != "":
+		password, err := adminPassword(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return utils.KeystoreSigner(ctx.String("admin-keystore"), password)
+	case ctx.String("admin-key-env") != "":
+		env := ctx.String("admin-key-env")
+		key, ok := os.LookupEnv(env)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("admin key env %s is not set", env)
+		}
+		return utils.HexKeySigner(key)
+	case chain.AdminKey != "":
+		return utils.HexKeySigner(chain.AdminKey)
+	case ctx.Bool("dev"):
+		return utils.HexKeySigner(utils.AdminPrivateKey)
+	}
+	return nil, nil
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/axiomesh/data-producer/internal/repo"
 	"github.com/axiomesh/data-producer/internal/utils"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/urfave/cli/v2"
 )
 
+const DefaultAmount = "1ether"
+const DefaultFanout = 16
+const DefaultBatch = 100
+
 var balanceCMD = &cli.Command{
 	Name:  "balance",
 	Usage: "Accounts' Balance tools",
@@ -16,37 +27,208 @@ var balanceCMD = &cli.Command{
 		{
 			Name:  "init",
 			Usage: "Init Accounts' Balance",
-			Flags: []cli.Flag{
-				&cli.IntFlag{
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
 					Name:  "amount",
-					Usage: "Specify account's amount",
+					Usage: "Specify account's amount, e.g. 10ether, 500gwei or raw wei",
+					Value: DefaultAmount,
+				},
+				&cli.StringFlag{
+					Name:  "amount-max",
+					Usage: "Specify max account's amount, every account gets a random amount between amount and amount-max",
+				},
+				&cli.BoolFlag{
+					Name:  "top-up",
+					Usage: "Top up accounts to the amount, only sending the difference to their balance",
+				},
+				&cli.StringFlag{
+					Name:  "strategy",
+					Usage: "Specify funding strategy, direct funds every account from the admin, tree funds them along a fan-out tree of accounts, multisend funds them in batches through a multisend contract",
+					Value: "direct",
+				},
+				&cli.IntFlag{
+					Name:  "fanout",
+					Usage: "Specify accounts funded by every account of the tree strategy",
+					Value: DefaultFanout,
 				},
-			},
+				&cli.IntFlag{
+					Name:  "batch",
+					Usage: "Specify accounts funded by every transaction of the multisend strategy",
+					Value: DefaultBatch,
+				},
+				&cli.StringFlag{
+					Name:  "multisend-address",
+					Usage: "Specify a deployed multisend contract, default deploys one",
+				},
+				&cli.BoolFlag{
+					Name:  "resume",
+					Usage: "Resume funding from the profile's checkpoint, skipping confirmed accounts and retrying failed ones",
+				},
+			}, gasFlags...),
 			Action: InitAccountsBalance,
 		},
+		{
+			Name:  "reclaim",
+			Usage: "Reclaim Accounts' Balance to the admin",
+			Flags: append([]cli.Flag{
+				&cli.StringFlag{
+					Name:  "to",
+					Usage: "Specify address receiving the balances, default is the admin",
+				},
+				&cli.BoolFlag{
+					Name:  "resume",
+					Usage: "Resume reclaiming from the profile's checkpoint, skipping confirmed accounts and retrying failed ones",
+				},
+			}, gasFlags...),
+			Action: ReclaimAccountsBalance,
+		},
 	},
 }
 
 func InitAccountsBalance(ctx *cli.Context) error {
+	amount, err := amountFunc(ctx)
+	if err != nil {
+		return err
+	}
+	// the least amount of the range, what resumed tree parents keep
+	keep, err := utils.ParseAmount(ctx.String("amount"))
+	if err != nil {
+		return err
+	}
+	topUp := ctx.Bool("top-up")
+	strategy := ctx.String("strategy")
+	if strategy != "direct" && strategy != "tree" && strategy != "multisend" {
+		return fmt.Errorf("unsupported strategy %s, should be direct, tree or multisend", strategy)
+	}
+	fanout := ctx.Int("fanout")
+	if fanout < 2 {
+		return fmt.Errorf("fanout should be at least 2")
+	}
+	batch := ctx.Int("batch")
+	if batch <= 0 {
+		return fmt.Errorf("batch should be positive")
+	}
+	parallel := ctx.Int("parallel")
+	if parallel <= 0 {
+		return fmt.Errorf("parallel should be positive")
+	}
+
 	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
+	err = initClient(ctx)
 	if err != nil {
 		return err
 	}
+	if utils.Admin() == nil {
+		return fmt.Errorf("%w, use --admin-key-env, --admin-keystore, --admin-signer or --dev", utils.ErrNoAdmin)
+	}
 
-	accounts, err := IsInitAccounts()
+	r, err := loadRepo(ctx)
 	if err != nil {
 		return err
 	}
+	store, err := IsInitAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
 
-	quantity := len(accounts)
-	var parallel, cnt int
-	if quantity <= DefaultParallel {
+	checkpoint, err := openCheckpoint(r.FundingPath(), "init", store, ctx.Bool("resume"), parallel)
+	if err != nil {
+		return err
+	}
+	defer checkpoint.Close()
+
+	targets, values, err := fundValues(store, checkpoint, amount, topUp, parallel)
+	if err != nil {
+		return err
+	}
+	switch strategy {
+	case "tree":
+		err = fundTree(store, checkpoint, values, keep, fanout, parallel)
+	case "multisend":
+		err = fundMultisend(store, checkpoint, values, batch, parallel, ctx.String("multisend-address"))
+	default:
+		err = fundDirect(store, checkpoint, values, parallel)
+	}
+	if err != nil {
+		return err
+	}
+	err = checkpointSummary(checkpoint, "init", "funded")
+	if err != nil || strategy == "direct" {
+		return err
+	}
+	return verifyBalances(store, targets, parallel)
+}
+
+// openCheckpoint creates the checkpoint of balance command, or opens it to
+// resume where pending transactions are waited for: confirmed ones are kept
+// and failed ones retried. Transactions still not mined may be mined later
+// and are never sent again, resuming stops until they are.
+func openCheckpoint(path, command string, store repo.AccountStore, resume bool, parallel int) (*repo.Checkpoint, error) {
+	if !resume {
+		return repo.CreateCheckpoint(path, store)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("no checkpoint to resume, run balance %s first", command)
+	}
+	checkpoint, err := repo.OpenCheckpoint(path, store)
+	if err != nil {
+		return nil, err
+	}
+	// accounts of a multisend batch share its transaction
+	pending := make(map[common.Hash][]int)
+	var hashes []common.Hash
+	for i := 0; i < checkpoint.Len(); i++ {
+		status, hash, err := checkpoint.Get(i)
+		if err != nil {
+			checkpoint.Close()
+			return nil, err
+		}
+		if status != repo.TxPending {
+			continue
+		}
+		if _, ok := pending[hash]; !ok {
+			hashes = append(hashes, hash)
+		}
+		pending[hash] = append(pending[hash], i)
+	}
+	var unmined int64
+	parallelRange(0, len(hashes), parallel, func(i int) {
+		hash := hashes[i]
+		err := utils.WaitReceipt(hash)
+		switch {
+		case errors.Is(err, utils.ErrReceiptTimeout):
+			atomic.AddInt64(&unmined, int64(len(pending[hash])))
+		case err != nil:
+			markBatch(checkpoint, pending[hash], repo.TxFailed, hash)
+		default:
+			markBatch(checkpoint, pending[hash], repo.TxConfirmed, hash)
+		}
+	})
+	if unmined > 0 {
+		checkpoint.Close()
+		hint := "start over without --resume"
+		if command == "init" {
+			hint = "start over with --top-up"
+		}
+		return nil, fmt.Errorf("%d accounts still have a transaction not mined, resume once it is or %s", unmined, hint)
+	}
+	return checkpoint, nil
+}
+
+// fundDirect funds every account from the admin.
+func fundDirect(store repo.AccountStore, checkpoint *repo.Checkpoint, values []*big.Int, parallel int) error {
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return err
+	}
+
+	quantity := store.Len()
+	var cnt int
+	if quantity <= parallel {
 		parallel = 1
 		cnt = quantity
 	} else {
-		parallel = DefaultParallel
 		cnt = quantity / parallel
 		if quantity%parallel != 0 {
 			parallel++
@@ -57,6 +239,7 @@ func InitAccountsBalance(ctx *cli.Context) error {
 	wg.Add(parallel)
 	for i := 0; i < parallel; i++ {
 		go func(idx int) {
+			defer wg.Done()
 			var end int
 			if idx == parallel-1 {
 				end = quantity
@@ -65,21 +248,143 @@ func InitAccountsBalance(ctx *cli.Context) error {
 			}
 
 			for j := idx * cnt; j < end; j++ {
-				key, err := crypto.HexToECDSA(accounts[j])
+				if values[j].Sign() == 0 {
+					continue
+				}
+				account, err := store.Get(j)
 				if err != nil {
 					fmt.Println(err)
-					return
+					markCheckpoint(checkpoint, j, repo.TxFailed, common.Hash{})
+					continue
 				}
-				err = utils.TransferFromAdmin(key)
+				hash, err := utils.SendTransfer(utils.Admin(), account.Address, values[j], pricer.Fees())
 				if err != nil {
 					fmt.Println(err)
-					return
+					markCheckpoint(checkpoint, j, repo.TxFailed, common.Hash{})
+					continue
 				}
+				markCheckpoint(checkpoint, j, repo.TxPending, hash)
+				err = utils.WaitReceipt(hash)
+				if errors.Is(err, utils.ErrReceiptTimeout) {
+					// may still be mined, left pending for --resume
+					fmt.Println(err)
+					continue
+				}
+				if err != nil {
+					fmt.Println(err)
+					markCheckpoint(checkpoint, j, repo.TxFailed, hash)
+					continue
+				}
+				markCheckpoint(checkpoint, j, repo.TxConfirmed, hash)
 			}
-			wg.Done()
 		}(i)
 	}
 	wg.Wait()
+	return nil
+}
+
+func markCheckpoint(checkpoint *repo.Checkpoint, i int, status repo.TxStatus, hash common.Hash) {
+	err := checkpoint.Set(i, status, hash)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
 
+// checkpointSummary prints the statuses of balance command and fails when
+// accounts are left to retry.
+func checkpointSummary(checkpoint *repo.Checkpoint, command, done string) error {
+	counts := make(map[repo.TxStatus]int)
+	for i := 0; i < checkpoint.Len(); i++ {
+		status, _, err := checkpoint.Get(i)
+		if err != nil {
+			return err
+		}
+		counts[status]++
+	}
+	fmt.Printf("%d confirmed, %d pending, %d failed, %d skipped\n", counts[repo.TxConfirmed], counts[repo.TxPending], counts[repo.TxFailed], counts[repo.TxNone])
+	if counts[repo.TxPending]+counts[repo.TxFailed] > 0 {
+		return fmt.Errorf("%d accounts not %s, run balance %s --resume to retry", counts[repo.TxPending]+counts[repo.TxFailed], done, command)
+	}
 	return nil
 }
+
+// amountFunc returns the amount of every account, fixed or random between
+// --amount and --amount-max.
+func amountFunc(ctx *cli.Context) (func(rnd *rand.Rand) *big.Int, error) {
+	amount, err := utils.ParseAmount(ctx.String("amount"))
+	if err != nil {
+		return nil, err
+	}
+	if ctx.String("amount-max") == "" {
+		return func(*rand.Rand) *big.Int {
+			return amount
+		}, nil
+	}
+	max, err := utils.ParseAmount(ctx.String("amount-max"))
+	if err != nil {
+		return nil, err
+	}
+	if max.Cmp(amount) < 0 {
+		return nil, fmt.Errorf("amount-max %s is less than amount %s", ctx.String("amount-max"), ctx.String("amount"))
+	}
+	span := new(big.Int).Sub(max, amount)
+	span.Add(span, big.NewInt(1))
+	return func(rnd *rand.Rand) *big.Int {
+		value := new(big.Int).Rand(rnd, span)
+		return value.Add(value, amount)
+	}, nil
+}
+
+// fundValues returns the target balance and the value to send of every
+// account, nothing for accounts the checkpoint has confirmed.
+func fundValues(store repo.AccountStore, checkpoint *repo.Checkpoint, amount func(rnd *rand.Rand) *big.Int, topUp bool, parallel int) ([]*big.Int, []*big.Int, error) {
+	targets := make([]*big.Int, store.Len())
+	values := make([]*big.Int, store.Len())
+	var errOnce sync.Once
+	var firstErr error
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := range targets {
+		targets[i] = amount(rnd)
+	}
+	parallelRange(0, store.Len(), parallel, func(i int) {
+		status, _, err := checkpoint.Get(i)
+		if err == nil && status == repo.TxConfirmed {
+			targets[i] = new(big.Int)
+			values[i] = new(big.Int)
+			return
+		}
+		account, err := store.Get(i)
+		if err == nil {
+			values[i], err = fundValue(account, targets[i], topUp)
+		}
+		if err != nil {
+			errOnce.Do(func() {
+				firstErr = err
+			})
+		}
+	})
+	if firstErr != nil {
+		return nil, nil, firstErr
+	}
+	if !topUp {
+		// funded on top of the balance, verify at least the amount
+		return values, values, nil
+	}
+	return targets, values, nil
+}
+
+// fundValue returns the value to send an account, the difference to its
+// balance in top-up mode.
+func fundValue(account *repo.Account, amount *big.Int, topUp bool) (*big.Int, error) {
+	if !topUp {
+		return amount, nil
+	}
+	balance, err := utils.Balance(account.Address)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(amount) >= 0 {
+		return new(big.Int), nil
+	}
+	return new(big.Int).Sub(amount, balance), nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const configMetadataKey = "config"
+
+// Config holds flag values by flag name, nested sections named after
+// commands hold the values of that command's flags, e.g.
+//
+//	url: http://localhost:8881
+//	generate:
+//	  quantity: 10000
+//	  mix:
+//	    weights: {eth_getBalance: 40, eth_call: 60}
+//
+// A flag takes the value of the closest section, flags set on the command
+// line or by env always win.
+type Config map[string]interface{}
+
+func LoadConfig(path string) (Config, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// decode into a plain map so nested sections are plain maps too
+	cfg := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		err = toml.Unmarshal(bytes, &cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(bytes, &cfg)
+	default:
+		return nil, fmt.Errorf("unsupported config %s, should be yaml or toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse config %s failed: %w", path, err)
+	}
+	return Config(cfg), nil
+}
+
+// lookup returns the value of flag name for the command path, searching
+// from the command's own section up to the top level.
+func (c Config) lookup(path []string, name string) (interface{}, bool) {
+	sections := []map[string]interface{}{c}
+	for _, cmd := range path {
+		section, ok := sections[len(sections)-1][cmd].(map[string]interface{})
+		if !ok {
+			break
+		}
+		sections = append(sections, section)
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		if value, ok := sections[i][name]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// configValue formats a config value as flag input, lists are joined by
+// "," and maps become "key=value" pairs.
+func configValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = configValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, k := range keys {
+			items[i] = k + "=" + configValue(v[k])
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// loadConfig loads --config, or the selected profile's config file if
+// there is one, and applies it to the global flags.
+func loadConfig(ctx *cli.Context) error {
+	path := ctx.String("config")
+	explicit := path != ""
+	if !explicit {
+		r, err := loadRepo(ctx)
+		if err != nil {
+			return err
+		}
+		path = r.ConfigPath()
+		if path == "" {
+			return nil
+		}
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	ctx.App.Metadata[configMetadataKey] = cfg
+
+	skip := map[string]bool{"config": true}
+	if !explicit {
+		// the profile is already chosen by the time its config is read
+		skip["home"] = true
+		skip["profile"] = true
+	}
+	return applyConfig(ctx, cfg, nil, ctx.App.Flags, skip)
+}
+
+// applyCommandConfig applies the loaded config to the flags of the running
+// command and its parents, once the final subcommand is known so that its
+// section takes precedence.
+func applyCommandConfig(ctx *cli.Context) error {
+	cfg, ok := ctx.App.Metadata[configMetadataKey].(Config)
+	if !ok {
+		return nil
+	}
+	if ctx.Args().Present() && ctx.Command.Subcommands != nil && ctx.Command.Command(ctx.Args().First()) != nil {
+		return nil
+	}
+	var path []string
+	var flags []cli.Flag
+	lineage := ctx.Lineage()
+	// the last contexts are the app's, skip them
+	for i := len(lineage) - 1; i >= 0; i-- {
+		if lineage[i].Command == nil || lineage[i].Command.Name == ctx.App.Name {
+			continue
+		}
+		path = append(path, lineage[i].Command.Name)
+		flags = append(flags, lineage[i].Command.Flags...)
+	}
+	return applyConfig(ctx, cfg, path, flags, nil)
+}
+
+func applyConfig(ctx *cli.Context, cfg Config, path []string, flags []cli.Flag, skip map[string]bool) error {
+	for _, flag := range flags {
+		name := flag.Names()[0]
+		if skip[name] || name == "help" || ctx.IsSet(name) {
+			continue
+		}
+		value, ok := cfg.lookup(path, name)
+		if !ok {
+			continue
+		}
+		err := ctx.Set(name, configValue(value))
+		if err != nil {
+			return fmt.Errorf("config %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// withConfig makes cmds and their subcommands apply the loaded config.
+func withConfig(cmds []*cli.Command) {
+	for _, cmd := range cmds {
+		if cmd.Before == nil {
+			cmd.Before = applyCommandConfig
+		}
+		withConfig(cmd.Subcommands)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"time"
 
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -22,17 +24,80 @@ func main() {
 				Usage: "JSON RPC url",
 				Value: "http://localhost:8881",
 			},
+			&cli.StringFlag{
+				Name:  "home",
+				Usage: "Specify data producer home directory",
+				Value: repo.DefaultDirPath,
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "Specify profile, with its own accounts, chain config and corpora",
+				Value: repo.DefaultProfile,
+			},
+			&cli.StringFlag{
+				Name:  "config",
+				Usage: "Specify yaml or toml config file, default is the profile's config.yaml or config.toml",
+			},
+			&cli.IntFlag{
+				Name:  "parallel",
+				Usage: "Specify parallelism of account creation, funding and generation",
+				Value: DefaultParallel,
+			},
+			&cli.StringFlag{
+				Name:  "password-file",
+				Usage: "Specify file holding the encrypted accounts passphrase",
+			},
+			&cli.StringFlag{
+				Name:  "admin-key-env",
+				Usage: "Specify env holding the hex admin private key funding accounts and deploying contracts",
+			},
+			&cli.StringFlag{
+				Name:  "admin-keystore",
+				Usage: "Specify keystore file of the admin",
+			},
+			&cli.StringFlag{
+				Name:  "admin-password-file",
+				Usage: "Specify file holding the admin keystore passphrase",
+			},
+			&cli.StringFlag{
+				Name:  "admin-signer",
+				Usage: "Specify url of an external clef compatible signer signing admin transactions",
+			},
+			&cli.StringFlag{
+				Name:  "admin-address",
+				Usage: "Specify admin address of the external signer, default is its first account",
+			},
+			&cli.BoolFlag{
+				Name:  "dev",
+				Usage: "Use the default axiom devnet admin key when no admin key is given",
+			},
+			&cli.StringFlag{
+				Name:  "tx-type",
+				Usage: "Specify type of funding, deployment and generated transactions, legacy, accesslist or dynamic",
+				Value: utils.TxTypeLegacy,
+			},
+			&cli.DurationFlag{
+				Name:  "receipt-timeout",
+				Usage: "Specify how long to wait for a transaction to be mined before leaving it pending",
+				Value: utils.DefaultReceiptTimeout,
+			},
 		},
+		Before: loadConfig,
 	}
 
 	app.Commands = cli.Commands{
 		initCMD,
 		balanceCMD,
 		generateCMD,
+		runCMD,
+		reportCMD,
+		profileCMD,
 	}
+	withConfig(app.Commands)
 
 	err := app.Run(os.Args)
 	if err != nil {
 		fmt.Println(err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// gasFlags select the gas strategy of the commands sending or signing
+// transactions.
+var gasFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "gas-price",
+		Usage: "Specify gas price strategy, suggested[:<multiplier>], fixed:<price>, escalating:<step%>[:<max price>] or random:<min price>-<max price>, the priority fee of dynamic fee transactions",
+		Value: utils.GasPriceSuggested,
+	},
+	&cli.StringFlag{
+		Name:  "gas-limit",
+		Usage: "Specify gas limit strategy, default, a fixed limit or estimate[:<pad%>]",
+		Value: utils.GasLimitDefault,
+	},
+}
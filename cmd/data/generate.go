@@ -1,221 +1,517 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/axiomesh/data-producer/internal/model"
+	"github.com/axiomesh/data-producer/internal/repo"
 	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/urfave/cli/v2"
 )
 
 var generateCMD = &cli.Command{
 	Name:  "generate",
 	Usage: "Generate stress testing testdata",
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.IntFlag{
 			Name:  "quantity",
-			Usage: "Specify testdata quantity, should less or equal account number, Max(2000000)",
+			Usage: "Specify testdata quantity, should less or equal account number times txs-per-account, Max(2000000)",
 			Value: DefaultQuantity,
 		},
-	},
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Specify output format, csv, jsonl, vegeta, k6 or wrk",
+			Value: model.FormatCSV,
+		},
+		&cli.StringFlag{
+			Name:  "out-dir",
+			Usage: "Specify output directory, default is the profile's corpora directory",
+		},
+		&cli.StringFlag{
+			Name:  "id-scheme",
+			Usage: "Specify request id scheme, sequential or uuid",
+			Value: model.IDSchemeSequential,
+		},
+		&cli.BoolFlag{
+			Name:  "jmx",
+			Usage: "Emit a JMeter test plan replaying the csv testdata",
+		},
+		&cli.IntFlag{
+			Name:  "jmx-threads",
+			Usage: "Specify JMeter test plan thread count",
+			Value: DefaultParallel,
+		},
+		&cli.DurationFlag{
+			Name:  "jmx-duration",
+			Usage: "Specify JMeter test plan duration",
+			Value: time.Minute,
+		},
+	}, gasFlags...),
 	Subcommands: []*cli.Command{
 		{
 			Name:   "eth_getBalance",
 			Usage:  "Generate eth_getBalance's testdata",
-			Action: generateEthGetBalance,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getBlockByNumber",
 			Usage:  "Generate eth_getBlockByNumber's testdata",
-			Action: generateEthGetBlockByNumber,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getBlockByHash",
 			Usage:  "Generate eth_getBlockByHash's testdata",
-			Action: generateEthGetBlockByHash,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getCode",
 			Usage:  "Generate eth_getCode's testdata",
-			Action: generateEthGetCode,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getStorageAt",
 			Usage:  "Generate eth_getStorageAt's testdata",
-			Action: generateEthGetStorageAt,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_call",
 			Usage:  "Generate eth_call's testdata",
-			Action: generateEthCall,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_estimateGas",
 			Usage:  "Generate eth_estimateGas's testdata",
-			Action: generateEthEstimateGas,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getBlockTransactionCountByNumber",
 			Usage:  "Generate eth_getBlockTransactionCountByNumber's testdata",
-			Action: generateEthGetBlockTransactionCountByNumber,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getBlockTransactionCountByHash",
 			Usage:  "Generate eth_getBlockTransactionCountByHash's testdata",
-			Action: generateEthGetBlockTransactionCountByHash,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getTransactionByBlockNumberAndIndex",
 			Usage:  "Generate eth_getTransactionByBlockNumberAndIndex's testdata",
-			Action: generateEthGetTransactionByBlockNumberAndIndex,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getTransactionByBlockHashAndIndex",
 			Usage:  "Generate eth_getTransactionByBlockHashAndIndex's testdata",
-			Action: generateEthGetTransactionByBlockHashAndIndex,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getTransactionCount",
 			Usage:  "Generate eth_getTransactionCount's testdata",
-			Action: generateEthGetTransactionCount,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getTransactionByHash",
 			Usage:  "Generate eth_getTransactionByHash's testdata",
-			Action: generateEthGetTransactionByHash,
+			Action: generateMethod,
 		},
 		{
 			Name:   "eth_getTransactionReceipt",
 			Usage:  "Generate eth_getTransactionReceipt's testdata",
-			Action: generateEthGetTransactionReceipt,
+			Action: generateMethod,
+		},
+		{
+			Name:  "eth_sendRawTransaction",
+			Usage: "Generate eth_sendRawTransaction's testdata",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "txs-per-account",
+					Usage: "Specify transactions signed by every account with consecutive nonces",
+					Value: 1,
+				},
+				&cli.BoolFlag{
+					Name:  "fresh-nonces",
+					Usage: "Start every account's nonces at 0 instead of querying the node, for accounts that never sent a transaction",
+				},
+			},
+			Action: generateMethod,
 		},
 		{
-			Name:   "eth_sendRawTransaction",
-			Usage:  "Generate eth_sendRawTransaction's testdata",
-			Action: generateEthSendRawTransaction,
+			Name:  "mix",
+			Usage: "Generate mixed testdata interleaving several methods by weight",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "weights",
+					Usage: "Specify method weights, e.g. eth_getBalance=40,eth_call=30,eth_sendRawTransaction=10",
+				},
+				&cli.IntFlag{
+					Name:  "txs-per-account",
+					Usage: "Specify requests made by every account, its transactions take consecutive nonces",
+					Value: 1,
+				},
+				&cli.BoolFlag{
+					Name:  "fresh-nonces",
+					Usage: "Start every account's nonces at 0 instead of querying the node, for accounts that never sent a transaction",
+				},
+			},
+			Action: generateMix,
 		},
 	},
 }
 
-func generate(ctx *cli.Context, fn func(account string) (*model.EthReq, error)) error {
+type reqFunc func(account *repo.Account) (*model.EthReq, error)
+
+// generators prepare the chain state a method needs, e.g. deploy a
+// contract, and return the builder of its requests.
+var generators = map[string]func(ctx *cli.Context) (reqFunc, error){
+	"eth_getBalance":                          ethGetBalance,
+	"eth_getBlockByNumber":                    ethGetBlockByNumber,
+	"eth_getBlockByHash":                      ethGetBlockByHash,
+	"eth_getCode":                             ethGetCode,
+	"eth_getStorageAt":                        ethGetStorageAt,
+	"eth_call":                                ethCall,
+	"eth_estimateGas":                         ethEstimateGas,
+	"eth_getBlockTransactionCountByNumber":    ethGetBlockTransactionCountByNumber,
+	"eth_getBlockTransactionCountByHash":      ethGetBlockTransactionCountByHash,
+	"eth_getTransactionByBlockNumberAndIndex": ethGetTransactionByBlockNumberAndIndex,
+	"eth_getTransactionByBlockHashAndIndex":   ethGetTransactionByBlockHashAndIndex,
+	"eth_getTransactionCount":                 ethGetTransactionCount,
+	"eth_getTransactionByHash":                ethGetTransactionByHash,
+	"eth_getTransactionReceipt":               ethGetTransactionReceipt,
+	"eth_sendRawTransaction":                  ethSendRawTransaction,
+}
+
+func generateMethod(ctx *cli.Context) error {
+	// init rpc client
+	err := initClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	fn, err := generators[ctx.Command.Name](ctx)
+	if err != nil {
+		return err
+	}
+	return generate(ctx, fn, nil)
+}
+
+func generateMix(ctx *cli.Context) error {
+	weights, err := parseWeights(ctx.String("weights"))
+	if err != nil {
+		return err
+	}
+
+	// init rpc client
+	err = initClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	methods := make([]string, 0, len(weights))
+	for method := range weights {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	fns := make([]reqFunc, len(methods))
+	var total int
+	for i, method := range methods {
+		fns[i], err = generators[method](ctx)
+		if err != nil {
+			return fmt.Errorf("prepare %s failed: %w", method, err)
+		}
+		total += weights[method]
+	}
+
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		n := rand.Intn(total)
+		for i, method := range methods {
+			n -= weights[method]
+			if n < 0 {
+				return fns[i](account)
+			}
+		}
+		return fns[len(fns)-1](account)
+	}
+	return generate(ctx, fn, weights)
+}
+
+// parseWeights parses method weights in the form of "eth_getBalance=40,eth_call=30".
+func parseWeights(s string) (map[string]int, error) {
+	weights := make(map[string]int)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		parts := strings.Split(item, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid weight %q, should be method=weight", item)
+		}
+		method := strings.TrimSpace(parts[0])
+		if _, ok := generators[method]; !ok {
+			return nil, fmt.Errorf("unsupported method %q", method)
+		}
+		if _, ok := weights[method]; ok {
+			return nil, fmt.Errorf("duplicate weight of %s", method)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %q: %w", item, err)
+		}
+		if weight <= 0 {
+			return nil, fmt.Errorf("invalid weight %q, should be positive", item)
+		}
+		weights[method] = weight
+	}
+	if len(weights) == 0 {
+		return nil, fmt.Errorf("no weight found in %q", s)
+	}
+	return weights, nil
+}
+
+func generate(ctx *cli.Context, fn reqFunc, weights map[string]int) error {
 	quantity := ctx.Int("quantity")
 	if quantity > DefaultQuantity {
 		return fmt.Errorf("quantity is large than max quantity(%d)", DefaultQuantity)
 	}
-	accounts, err := IsInitAccounts()
+	// methods without the flag make one request per account
+	perAccount := ctx.Int("txs-per-account")
+	if perAccount == 0 && !ctx.IsSet("txs-per-account") {
+		perAccount = 1
+	}
+	if perAccount <= 0 {
+		return fmt.Errorf("txs-per-account should be positive")
+	}
+	accountNum := (quantity + perAccount - 1) / perAccount
+	store, err := IsInitAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	if store.Len() < accountNum {
+		return fmt.Errorf("quantity is large than account number(%d) times txs-per-account(%d)", store.Len(), perAccount)
+	}
+
+	format := ctx.String("format")
+	if ctx.Bool("jmx") && format != model.FormatCSV {
+		return fmt.Errorf("jmx test plan needs csv format")
+	}
+	ids, err := model.NewIDGenerator(ctx.String("id-scheme"))
+	if err != nil {
+		return err
+	}
+	url, err := rpcURL(ctx)
+	if err != nil {
+		return err
+	}
+	outDir, err := outputDir(ctx)
 	if err != nil {
 		return err
 	}
-	if len(accounts) < quantity {
-		return fmt.Errorf("quantity is large than account number(%d)", len(accounts))
+	ext := model.FormatExt(format)
+	timestamp := time.Now().Format("200601021504")
+	filename := filepath.Join(outDir, fmt.Sprintf("%s-%d-%s.%s", ctx.Command.Name, quantity, timestamp, ext))
+	writer, err := model.NewReqWriter(format, filename, url)
+	if err != nil {
+		return err
 	}
-	var reqs []*model.EthReq
-	var parallel, cnt int
-	if quantity <= DefaultParallel {
+
+	// stop producing on interrupt, rows written so far stay a valid file
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	produceCtx, cancel := context.WithCancel(sigCtx)
+	defer cancel()
+
+	parallel := ctx.Int("parallel")
+	if parallel <= 0 {
+		return fmt.Errorf("parallel should be positive")
+	}
+	var cnt int
+	if accountNum <= parallel {
 		parallel = 1
-		cnt = quantity
+		cnt = accountNum
 	} else {
-		parallel = DefaultParallel
-		cnt = quantity / parallel
-		if quantity%parallel != 0 {
+		cnt = accountNum / parallel
+		if accountNum%parallel != 0 {
 			parallel++
 		}
 	}
 
+	reqCh := make(chan *model.EthReq, parallel*128)
+	// the first failing worker stops the others
+	var produceErr error
+	var errOnce sync.Once
+	fail := func(err error) {
+		errOnce.Do(func() {
+			produceErr = err
+		})
+		cancel()
+	}
 	wg := sync.WaitGroup{}
 	wg.Add(parallel)
-	lock := sync.Mutex{}
 	for i := 0; i < parallel; i++ {
 		go func(idx int) {
+			defer wg.Done()
 			var end int
 			if idx == parallel-1 {
-				end = quantity
+				end = accountNum
 			} else {
 				end = (idx + 1) * cnt
 			}
 
-			for j := idx * cnt; j < end; j++ {
-				req, err := fn(accounts[j])
+			for i := idx * cnt; i < end; i++ {
+				// accounts are read one by one so the store stays mapped
+				account, err := store.Get(i)
 				if err != nil {
-					fmt.Println(err)
-					wg.Done()
+					fail(err)
 					return
 				}
-				lock.Lock()
-				reqs = append(reqs, req)
-				lock.Unlock()
+				// the last account may make fewer requests
+				n := quantity - i*perAccount
+				if n > perAccount {
+					n = perAccount
+				}
+				for j := 0; j < n; j++ {
+					req, err := fn(account)
+					if err != nil {
+						fail(err)
+						return
+					}
+					select {
+					case reqCh <- req:
+					case <-produceCtx.Done():
+						return
+					}
+				}
 			}
-			wg.Done()
 		}(i)
 	}
-	wg.Wait()
+	go func() {
+		wg.Wait()
+		close(reqCh)
+	}()
 
-	filename := fmt.Sprintf("%s-%d-%s.csv", ctx.Command.Name, len(reqs), time.Now().Format("200601021504"))
-	err = model.CreateAndWriteReqs(filename, reqs)
+	var writeErr error
+	for req := range reqCh {
+		if writeErr != nil {
+			continue
+		}
+		req.ID = ids.Next()
+		writeErr = writer.Write(req)
+		if writeErr != nil {
+			cancel()
+		}
+	}
+	err = writer.Close()
+	if writeErr != nil {
+		return writeErr
+	}
 	if err != nil {
 		return err
 	}
+	if produceErr != nil {
+		// a corpus missing a worker's share is no use, unlike an interrupted one
+		_ = os.Remove(filename)
+		return fmt.Errorf("generation failed: %w", produceErr)
+	}
 
-	return nil
-}
+	if writer.Count() != quantity {
+		actual := filepath.Join(outDir, fmt.Sprintf("%s-%d-%s.%s", ctx.Command.Name, writer.Count(), timestamp, ext))
+		err = os.Rename(filename, actual)
+		if err != nil {
+			return err
+		}
+		filename = actual
+	}
 
-func generateEthGetBalance(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
+	manifest := &model.Manifest{
+		File:     filepath.Base(filename),
+		Method:   ctx.Command.Name,
+		Format:   format,
+		IDScheme: ctx.String("id-scheme"),
+		Weights:  weights,
+		Count:    writer.Count(),
+		URL:      url,
+		Created:  time.Now(),
+	}
+	err = manifest.Write(model.ManifestPath(filename))
 	if err != nil {
 		return err
 	}
+	if sigCtx.Err() != nil {
+		return fmt.Errorf("generation interrupted, %d requests written to %s", writer.Count(), filename)
+	}
+	fmt.Printf("%d requests written to %s\n", writer.Count(), filename)
 
-	fn := func(account string) (*model.EthReq, error) {
-		key, err := crypto.HexToECDSA(account)
+	if ctx.Bool("jmx") {
+		jmxFile := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".jmx"
+		err = model.WriteJMX(jmxFile, &model.JMXPlan{
+			Name:     ctx.Command.Name,
+			CSVFile:  filepath.Base(filename),
+			URL:      url,
+			Threads:  ctx.Int("jmx-threads"),
+			Duration: int(ctx.Duration("jmx-duration").Seconds()),
+		})
 		if err != nil {
-			return nil, err
+			return err
 		}
-		address := crypto.PubkeyToAddress(key.PublicKey).String()
-		req := model.NewGetBalanceReq(address)
+		fmt.Printf("JMeter test plan written to %s\n", jmxFile)
+	}
+	return nil
+}
+
+// outputDir returns --out-dir or the profile's corpora directory, creating it if needed.
+func outputDir(ctx *cli.Context) (string, error) {
+	dir := ctx.String("out-dir")
+	if dir == "" {
+		r, err := loadRepo(ctx)
 		if err != nil {
-			return nil, err
+			return "", err
 		}
-		return req, nil
+		dir = r.CorporaPath()
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return "", err
 	}
-	return generate(ctx, fn)
+	return dir, nil
 }
 
-func generateEthGetBlockByNumber(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
+func ethGetBalance(ctx *cli.Context) (reqFunc, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		address := account.Address.String()
+		req := model.NewGetBalanceReq(address)
+		return req, nil
 	}
+	return fn, nil
+}
 
+func ethGetBlockByNumber(ctx *cli.Context) (reqFunc, error) {
 	highMax, err := utils.GetBlockHighMax()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		number := rand.Intn(highMax) + 1
 		req := model.NewGetBlockByNumberReq(number)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetBlockByHash(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
-	fn := func(account string) (*model.EthReq, error) {
+func ethGetBlockByHash(ctx *cli.Context) (reqFunc, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		hash, err := utils.GetBlockRandomHash()
 		if err != nil {
 			return nil, err
@@ -223,73 +519,44 @@ func generateEthGetBlockByHash(ctx *cli.Context) error {
 		req := model.NewGetBlockByHashReq(hash)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetCode(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetCode(ctx *cli.Context) (reqFunc, error) {
 	contractAddr, err := utils.DeployContract()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewGetCodeReq(contractAddr)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetStorageAt(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
-	fn := func(account string) (*model.EthReq, error) {
-		key, err := crypto.HexToECDSA(account)
-		if err != nil {
-			return nil, err
-		}
-		address := crypto.PubkeyToAddress(key.PublicKey).String()
+func ethGetStorageAt(ctx *cli.Context) (reqFunc, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		address := account.Address.String()
 		req := model.NewGetStorageAtReq(address)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthCall(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethCall(ctx *cli.Context) (reqFunc, error) {
 	// deploy contract
 	contractAddr, err := utils.DeployContract()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// set value
 	err = utils.Store(contractAddr, 1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// get value
-	fn := func(account string) (*model.EthReq, error) {
-		key, err := crypto.HexToECDSA(account)
-		if err != nil {
-			return nil, err
-		}
-		address := crypto.PubkeyToAddress(key.PublicKey).String()
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		address := account.Address.String()
 		msg, err := utils.GenRetrieveMsg(contractAddr, address)
 		if err != nil {
 			return nil, err
@@ -297,186 +564,151 @@ func generateEthCall(ctx *cli.Context) error {
 		req := model.NewCallReq(msg)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthEstimateGas(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethEstimateGas(ctx *cli.Context) (reqFunc, error) {
 	// deploy contract
 	contractAddr, err := utils.DeployContract()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return nil, err
+	}
+	gasLimit, err := utils.StoreGasLimit(contractAddr, utils.Admin().Address())
+	if err != nil {
+		return nil, err
 	}
 	// generate msg
-	tx, err := utils.GenEstimateGasTx(contractAddr, utils.AdminPrivateKey, 1)
+	tx, err := utils.GenEstimateGasTx(contractAddr, utils.Admin(), gasLimit, pricer.Fees(), 1)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewEstimateGasReq(tx)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetBlockTransactionCountByNumber(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetBlockTransactionCountByNumber(ctx *cli.Context) (reqFunc, error) {
 	max, err := utils.GetBlockHighMax()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		number := rand.Intn(max) + 1
 		req := model.NewGetBlockTransactionCountByNumberReq(number)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetBlockTransactionCountByHash(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetBlockTransactionCountByHash(ctx *cli.Context) (reqFunc, error) {
 	hash, err := utils.GetBlockRandomHash()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewGetBlockTransactionCountByHashReq(hash)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetTransactionByBlockNumberAndIndex(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetTransactionByBlockNumberAndIndex(ctx *cli.Context) (reqFunc, error) {
 	max, err := utils.GetBlockHighMax()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		number := rand.Intn(max) + 1
 		req := model.NewGetTransactionByBlockNumberAndIndexReq(number, 0)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetTransactionByBlockHashAndIndex(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetTransactionByBlockHashAndIndex(ctx *cli.Context) (reqFunc, error) {
 	hash, err := utils.GetBlockRandomHash()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewGetTransactionByBlockHashAndIndexReq(hash, 0)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetTransactionCount(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
-	fn := func(account string) (*model.EthReq, error) {
-		key, err := crypto.HexToECDSA(account)
-		if err != nil {
-			return nil, err
-		}
-		address := crypto.PubkeyToAddress(key.PublicKey).String()
+func ethGetTransactionCount(ctx *cli.Context) (reqFunc, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		address := account.Address.String()
 		req := model.NewGetTransactionCountReq(address)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetTransactionByHash(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetTransactionByHash(ctx *cli.Context) (reqFunc, error) {
 	hash, err := utils.GetTxRandomHash()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewGetTransactionByHashReq(hash)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthGetTransactionReceipt(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethGetTransactionReceipt(ctx *cli.Context) (reqFunc, error) {
 	hash, err := utils.GetTxRandomHash()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
 		req := model.NewGetTransactionReceiptReq(hash)
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
 
-func generateEthSendRawTransaction(ctx *cli.Context) error {
-	// init rpc client
-	url := ctx.String("url")
-	err := utils.InitClient(url)
-	if err != nil {
-		return err
-	}
-
+func ethSendRawTransaction(ctx *cli.Context) (reqFunc, error) {
 	// deploy contract
 	contractAddr, err := utils.DeployContract()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return nil, err
+	}
+	// every transaction is the same call, estimated once
+	gasLimit, err := utils.StoreGasLimit(contractAddr, utils.Admin().Address())
+	if err != nil {
+		return nil, err
+	}
+	nonces := utils.Nonces()
+	if ctx.Bool("fresh-nonces") {
+		nonces = utils.NewNonceManager(func(common.Address) (uint64, error) {
+			return 0, nil
+		})
 	}
-	fn := func(account string) (*model.EthReq, error) {
+	fn := func(account *repo.Account) (*model.EthReq, error) {
+		key, err := account.PrivateKey()
+		if err != nil {
+			return nil, err
+		}
+		// the transactions of an account take consecutive nonces
+		nonce, err := nonces.Next(account.Address)
+		if err != nil {
+			return nil, err
+		}
 		// generate tx
-		tx, err := utils.GenRawTx(contractAddr, account, 1)
+		tx, err := utils.GenRawTx(contractAddr, key, nonce, gasLimit, pricer.Fees(), 1)
 		if err != nil {
 			return nil, err
 		}
@@ -487,5 +719,5 @@ func generateEthSendRawTransaction(ctx *cli.Context) error {
 		req := model.NewSendRawTransactionReq(hexutil.Encode(data))
 		return req, nil
 	}
-	return generate(ctx, fn)
+	return fn, nil
 }
@@ -1,14 +1,15 @@
 package main
 
 import (
-	"bufio"
+	"crypto/ecdsa"
 	"fmt"
-	"io"
 	"os"
 	"sync"
 
+	"github.com/axiomesh/data-producer/internal/hdwallet"
 	"github.com/axiomesh/data-producer/internal/repo"
-	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/urfave/cli/v2"
 )
@@ -22,82 +23,281 @@ var initCMD = &cli.Command{
 			Usage: "Specify Initialize Accounts Quantity",
 			Value: DefaultQuantity,
 		},
+		&cli.BoolFlag{
+			Name:  "encrypt",
+			Usage: "Encrypt accounts with a passphrase",
+		},
+		&cli.StringFlag{
+			Name:    "mnemonic",
+			Usage:   "Derive accounts from a BIP-39 mnemonic",
+			EnvVars: []string{"DATA_PRODUCER_MNEMONIC"},
+		},
+		&cli.StringFlag{
+			Name:  "mnemonic-passphrase",
+			Usage: "Specify BIP-39 mnemonic passphrase",
+		},
+		&cli.BoolFlag{
+			Name:  "new-mnemonic",
+			Usage: "Generate and print a new BIP-39 mnemonic to derive accounts from",
+		},
+		&cli.StringFlag{
+			Name:  "seed",
+			Usage: "Derive accounts from a hex BIP-32 seed",
+		},
+		&cli.StringFlag{
+			Name:  "hd-path",
+			Usage: "Specify BIP-44 base derivation path, the account index is appended",
+			Value: hdwallet.DefaultBasePath,
+		},
 	},
 	Action: initAccounts,
+	Subcommands: []*cli.Command{
+		{
+			Name:   "migrate",
+			Usage:  "Migrate text accounts to the binary accounts store",
+			Action: migrateAccounts,
+		},
+	},
 }
 
 func initAccounts(ctx *cli.Context) error {
 	quantity := ctx.Int("quantity")
-	parallel := DefaultParallel
+	parallel := ctx.Int("parallel")
+	if parallel <= 0 {
+		return fmt.Errorf("parallel should be positive")
+	}
+	encrypt := ctx.Bool("encrypt")
+
+	r, err := loadRepo(ctx)
+	if err != nil {
+		return err
+	}
+	textPath := r.AccountsPath()
+	binaryPath := r.BinaryAccountsPath()
+	encryptedPath := r.EncryptedAccountsPath()
 
-	dir, err := repo.DirPath()
+	// create default dir
+	err = r.Init()
 	if err != nil {
 		return err
 	}
-	accountsPath, err := repo.AccountsPath()
+
+	newKey, err := accountKeyFunc(ctx)
 	if err != nil {
 		return err
 	}
+	derived := newKey != nil
+	if !derived {
+		newKey = func(int) (*ecdsa.PrivateKey, error) {
+			return crypto.GenerateKey()
+		}
+	}
 
-	if _, err := os.Stat(accountsPath); os.IsNotExist(err) {
-		// create default dir
-		if _, err := os.Stat(dir); os.IsNotExist(err) {
-			err := os.Mkdir(dir, 0755)
+	// keep existing random accounts of the same quantity, derived accounts
+	// are always rewritten
+	var accounts []*repo.Account
+	if !derived {
+		if count, err := repo.EncryptedAccountsCount(encryptedPath); err == nil && count == quantity {
+			if encrypt {
+				return nil
+			}
+			password, err := accountsPassword(ctx, false)
+			if err != nil {
+				return err
+			}
+			store, err := repo.LoadEncryptedAccounts(encryptedPath, password)
+			if err != nil {
+				return err
+			}
+			accounts, err = store.Range(0, store.Len())
+			_ = store.Close()
+			if err != nil {
+				return err
+			}
+		} else if count, err := repo.BinaryAccountsCount(binaryPath); err == nil && count == quantity {
+			if !encrypt {
+				return nil
+			}
+			store, err := repo.OpenBinaryStore(binaryPath)
+			if err != nil {
+				return err
+			}
+			accounts, err = store.Range(0, store.Len())
+			_ = store.Close()
+			if err != nil {
+				return err
+			}
+		} else if keys, err := repo.LoadAccounts(textPath); err == nil && len(keys) == quantity {
+			accounts, err = repo.TextToAccounts(keys)
 			if err != nil {
 				return err
 			}
 		}
-		// create and write accounts
-		err := createAndWriteAccounts(quantity, parallel, accountsPath)
+	}
+
+	var password string
+	if encrypt {
+		password, err = accountsPassword(ctx, true)
 		if err != nil {
 			return err
 		}
-	} else {
-		accounts, err := repo.LoadAccounts(accountsPath)
+	}
+	if accounts == nil {
+		accounts, err = createAccounts(quantity, parallel, newKey)
 		if err != nil {
 			return err
 		}
-		if len(accounts) != quantity {
-			// create and write accounts
-			err := createAndWriteAccounts(quantity, parallel, accountsPath)
-			if err != nil {
-				return err
-			}
+	}
+
+	// keep a single accounts file
+	if encrypt {
+		err = repo.WriteEncryptedAccounts(encryptedPath, accounts, password)
+		if err != nil {
+			return err
 		}
+		return removeIfExist(textPath, binaryPath)
 	}
-	return nil
+	err = repo.WriteBinaryAccounts(binaryPath, accounts)
+	if err != nil {
+		return err
+	}
+	return removeIfExist(textPath, encryptedPath)
 }
 
-func createAndWriteAccounts(quantity, parallel int, path string) error {
-	file, err := os.Create(path)
+// migrateAccounts converts legacy text accounts to the binary store.
+func migrateAccounts(ctx *cli.Context) error {
+	r, err := loadRepo(ctx)
 	if err != nil {
 		return err
 	}
-	buf := bufio.NewWriter(file)
-	defer func(buf *bufio.Writer) {
-		err := buf.Flush()
+	textPath := r.AccountsPath()
+	binaryPath := r.BinaryAccountsPath()
+	encryptedPath := r.EncryptedAccountsPath()
+
+	if keys, err := repo.LoadAccounts(textPath); err == nil {
+		accounts, err := repo.TextToAccounts(keys)
+		if err != nil {
+			return err
+		}
+		err = repo.WriteBinaryAccounts(binaryPath, accounts)
 		if err != nil {
-			fmt.Println(err)
-			return
+			return err
 		}
-	}(buf)
+		fmt.Printf("%d accounts migrated to %s\n", len(accounts), binaryPath)
+		return removeIfExist(textPath)
+	}
+
+	if _, err := os.Stat(encryptedPath); err == nil {
+		password, err := accountsPassword(ctx, false)
+		if err != nil {
+			return err
+		}
+		store, err := repo.LoadEncryptedAccounts(encryptedPath, password)
+		if err != nil {
+			return err
+		}
+		accounts, err := store.Range(0, store.Len())
+		if err != nil {
+			return err
+		}
+		err = repo.WriteEncryptedAccounts(encryptedPath, accounts, password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d encrypted accounts migrated\n", len(accounts))
+		return nil
+	}
+
+	fmt.Println("no text accounts to migrate")
+	return nil
+}
+
+func removeIfExist(paths ...string) error {
+	for _, path := range paths {
+		err := os.Remove(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// accountKeyFunc returns the derivation of the account key at an index
+// from the mnemonic or seed flags, or nil for random accounts.
+func accountKeyFunc(ctx *cli.Context) (func(idx int) (*ecdsa.PrivateKey, error), error) {
+	var seed []byte
+	switch {
+	case ctx.IsSet("seed"):
+		if ctx.IsSet("mnemonic") || ctx.Bool("new-mnemonic") {
+			return nil, fmt.Errorf("seed and mnemonic are exclusive")
+		}
+		bytes, err := hexutil.Decode(ctx.String("seed"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed: %w", err)
+		}
+		seed = bytes
+	case ctx.IsSet("mnemonic") || ctx.Bool("new-mnemonic"):
+		mnemonic := ctx.String("mnemonic")
+		if ctx.Bool("new-mnemonic") {
+			if ctx.IsSet("mnemonic") {
+				return nil, fmt.Errorf("mnemonic and new-mnemonic are exclusive")
+			}
+			var err error
+			mnemonic, err = hdwallet.NewMnemonic()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("mnemonic: %s\n", mnemonic)
+		}
+		var err error
+		seed, err = hdwallet.SeedFromMnemonic(mnemonic, ctx.String("mnemonic-passphrase"))
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, nil
+	}
+
+	path, err := accounts.ParseDerivationPath(ctx.String("hd-path"))
+	if err != nil {
+		return nil, err
+	}
+	master, err := hdwallet.NewMaster(seed)
+	if err != nil {
+		return nil, err
+	}
+	base, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	return func(idx int) (*ecdsa.PrivateKey, error) {
+		child, err := base.Child(uint32(idx))
+		if err != nil {
+			return nil, err
+		}
+		return child.PrivateKey()
+	}, nil
+}
+
+func createAccounts(quantity, parallel int, newKey func(idx int) (*ecdsa.PrivateKey, error)) ([]*repo.Account, error) {
 	var cnt int
-	if quantity <= DefaultParallel {
+	if quantity <= parallel {
 		parallel = 1
 		cnt = quantity
 	} else {
-		parallel = DefaultParallel
 		cnt = quantity / parallel
 		if quantity%parallel != 0 {
 			parallel++
 		}
 	}
 
-	lock := sync.Mutex{}
+	accounts := make([]*repo.Account, quantity)
+	errs := make([]error, parallel)
 	wg := sync.WaitGroup{}
 	wg.Add(parallel)
 	for i := 0; i < parallel; i++ {
 		go func(idx int) {
+			defer wg.Done()
 			var end int
 			if idx == parallel-1 {
 				end = quantity
@@ -106,38 +306,58 @@ func createAndWriteAccounts(quantity, parallel int, path string) error {
 			}
 
 			for j := idx * cnt; j < end; j++ {
-				key, err := crypto.GenerateKey()
+				key, err := newKey(j)
 				if err != nil {
-					fmt.Println(err)
-					return
-				}
-				privateKeyHex := common.Bytes2Hex(crypto.FromECDSA(key))
-
-				lock.Lock()
-				_, err = io.WriteString(buf, privateKeyHex+"\n")
-				lock.Unlock()
-				if err != nil {
-					fmt.Println(err)
+					errs[idx] = err
 					return
 				}
+				accounts[j] = repo.NewAccount(j, key)
 			}
-			wg.Done()
 		}(i)
 	}
 	wg.Wait()
-	return nil
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accounts, nil
 }
 
-func IsInitAccounts() ([]string, error) {
-	accountsPath, err := repo.AccountsPath()
+func IsInitAccounts(ctx *cli.Context) (repo.AccountStore, error) {
+	r, err := loadRepo(ctx)
 	if err != nil {
 		return nil, err
 	}
-	accounts, err := repo.LoadAccounts(accountsPath)
+	binaryPath := r.BinaryAccountsPath()
+	if _, err := os.Stat(binaryPath); err == nil {
+		return repo.OpenBinaryStore(binaryPath)
+	}
+
+	encryptedPath := r.EncryptedAccountsPath()
+	if _, err := os.Stat(encryptedPath); err == nil {
+		password, err := accountsPassword(ctx, false)
+		if err != nil {
+			return nil, err
+		}
+		store, err := repo.LoadEncryptedAccounts(encryptedPath, password)
+		if err != nil {
+			fmt.Println("encrypted accounts load failed")
+			return nil, err
+		}
+		return store, nil
+	}
+
+	keys, err := repo.LoadAccounts(r.AccountsPath())
 	if err != nil {
 		fmt.Println("accounts load failed")
 		fmt.Println("please run init accounts first")
 		return nil, err
 	}
-	return accounts, nil
+	fmt.Println("loading text accounts, run init migrate to convert them to the faster binary store")
+	accounts, err := repo.TextToAccounts(keys)
+	if err != nil {
+		return nil, err
+	}
+	return repo.NewMemoryStore(repo.EncodeAccounts(accounts))
 }
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fundMultisend funds accounts in batches through the disperse contract,
+// one admin transaction per batch. The contract is deployed unless its
+// address is given.
+func fundMultisend(store repo.AccountStore, checkpoint *repo.Checkpoint, values []*big.Int, batch, parallel int, contractAddr string) error {
+	var batches [][]int
+	var current []int
+	for i, value := range values {
+		if value.Sign() == 0 {
+			continue
+		}
+		current = append(current, i)
+		if len(current) == batch {
+			batches = append(batches, current)
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	if len(batches) == 0 {
+		return nil
+	}
+	if contractAddr == "" {
+		var err error
+		contractAddr, err = utils.DeployDisperse()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("multisend contract deployed at %s\n", contractAddr)
+	}
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("funding accounts in %d multisend transactions of up to %d accounts\n", len(batches), batch)
+
+	var sent, confirmed int64
+	stop := showProgress(func() string {
+		return fmt.Sprintf("%d/%d sent, %d/%d confirmed", atomic.LoadInt64(&sent), len(batches), atomic.LoadInt64(&confirmed), len(batches))
+	})
+
+	// batches are sent in nonce order, receipts are awaited in parallel
+	hashes := make([]common.Hash, len(batches))
+	for b, indexes := range batches {
+		recipients := make([]common.Address, len(indexes))
+		amounts := make([]*big.Int, len(indexes))
+		for i, idx := range indexes {
+			account, err := store.Get(idx)
+			if err != nil {
+				stop()
+				return err
+			}
+			recipients[i] = account.Address
+			amounts[i] = values[idx]
+		}
+		hash, err := utils.SendDisperse(contractAddr, recipients, amounts, pricer.Fees())
+		if err != nil {
+			fmt.Printf("\nmultisend to accounts %d-%d failed: %s\n", indexes[0], indexes[len(indexes)-1], err)
+			markBatch(checkpoint, indexes, repo.TxFailed, common.Hash{})
+			continue
+		}
+		markBatch(checkpoint, indexes, repo.TxPending, hash)
+		hashes[b] = hash
+		atomic.AddInt64(&sent, 1)
+	}
+	parallelRange(0, len(hashes), parallel, func(i int) {
+		if hashes[i] == (common.Hash{}) {
+			return
+		}
+		err := utils.WaitReceipt(hashes[i])
+		if errors.Is(err, utils.ErrReceiptTimeout) {
+			// may still be mined, left pending for --resume
+			fmt.Printf("\n%s\n", err)
+			return
+		}
+		if err != nil {
+			fmt.Printf("\nmultisend %s failed: %s\n", hashes[i], err)
+			markBatch(checkpoint, batches[i], repo.TxFailed, hashes[i])
+			return
+		}
+		markBatch(checkpoint, batches[i], repo.TxConfirmed, hashes[i])
+		atomic.AddInt64(&confirmed, 1)
+	})
+	stop()
+	return nil
+}
+
+func markBatch(checkpoint *repo.Checkpoint, indexes []int, status repo.TxStatus, hash common.Hash) {
+	for _, i := range indexes {
+		markCheckpoint(checkpoint, i, status, hash)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+const PasswordEnv = "DATA_PRODUCER_PASSWORD"
+const AdminPasswordEnv = "DATA_PRODUCER_ADMIN_PASSWORD"
+
+// accountsPassword reads the accounts passphrase from --password-file, the
+// DATA_PRODUCER_PASSWORD env or an interactive prompt, confirm asks twice
+// when prompting.
+func accountsPassword(ctx *cli.Context, confirm bool) (string, error) {
+	return readPassword(ctx.String("password-file"), PasswordEnv, "Accounts passphrase", confirm)
+}
+
+// adminPassword reads the admin keystore passphrase from
+// --admin-password-file, the DATA_PRODUCER_ADMIN_PASSWORD env or an
+// interactive prompt.
+func adminPassword(ctx *cli.Context) (string, error) {
+	return readPassword(ctx.String("admin-password-file"), AdminPasswordEnv, "Admin keystore passphrase", false)
+}
+
+func readPassword(file, env, name string, confirm bool) (string, error) {
+	if file != "" {
+		bytes, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(bytes), "\r\n"), nil
+	}
+	if password, ok := os.LookupEnv(env); ok {
+		return password, nil
+	}
+
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("%s needed, use a password file or %s", strings.ToLower(name), env)
+	}
+	password, err := promptPassword(name + ": ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := promptPassword("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if password != again {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return password, nil
+}
+
+func promptPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+	bytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/urfave/cli/v2"
+)
+
+var profileCMD = &cli.Command{
+	Name:  "profile",
+	Usage: "Profile tools, every profile has its own accounts, chain config and corpora",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "List profiles",
+			Action: listProfiles,
+		},
+		{
+			Name:      "create",
+			Usage:     "Create a profile",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "chain-url",
+					Usage: "Specify the profile's JSON RPC url",
+				},
+				&cli.Uint64Flag{
+					Name:  "chain-id",
+					Usage: "Specify the profile's chain id, queried from the node when unset",
+				},
+				&cli.StringFlag{
+					Name:  "admin-key",
+					Usage: "Specify the profile's hex admin private key funding accounts",
+				},
+			},
+			Action: createProfile,
+		},
+		{
+			Name:      "delete",
+			Usage:     "Delete a profile with its accounts and corpora",
+			ArgsUsage: "<name>",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "yes",
+					Usage: "Confirm deleting the profile",
+				},
+			},
+			Action: deleteProfile,
+		},
+	},
+}
+
+// loadRepo returns the directory of the profile selected by --home and --profile.
+func loadRepo(ctx *cli.Context) (*repo.Repo, error) {
+	r, err := repo.New(ctx.String("home"), ctx.String("profile"))
+	if err != nil {
+		return nil, err
+	}
+	if r.Profile != repo.DefaultProfile && !r.Exists() {
+		return nil, fmt.Errorf("profile %s not found, create it with profile create", r.Profile)
+	}
+	return r, nil
+}
+
+// rpcURL returns --url when set, else the profile's url, else the --url default.
+func rpcURL(ctx *cli.Context) (string, error) {
+	if ctx.IsSet("url") {
+		return ctx.String("url"), nil
+	}
+	r, err := loadRepo(ctx)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := r.LoadChainConfig()
+	if err != nil {
+		return "", err
+	}
+	if cfg.URL != "" {
+		return cfg.URL, nil
+	}
+	return ctx.String("url"), nil
+}
+
+// initClient inits the rpc client with the profile's chain config.
+func initClient(ctx *cli.Context) error {
+	r, err := loadRepo(ctx)
+	if err != nil {
+		return err
+	}
+	chain, err := r.LoadChainConfig()
+	if err != nil {
+		return err
+	}
+	url, err := rpcURL(ctx)
+	if err != nil {
+		return err
+	}
+	admin, err := adminSigner(ctx, chain)
+	if err != nil {
+		return err
+	}
+	gas, err := utils.ParseGasStrategy(ctx.String("gas-price"), ctx.String("gas-limit"))
+	if err != nil {
+		return err
+	}
+	return utils.InitClient(&utils.ClientConfig{
+		URL:            url,
+		ChainID:        chain.ChainID,
+		Admin:          admin,
+		TxType:         ctx.String("tx-type"),
+		Gas:            gas,
+		ReceiptTimeout: ctx.Duration("receipt-timeout"),
+	})
+}
+
+func listProfiles(ctx *cli.Context) error {
+	profiles, err := repo.ListProfiles(ctx.String("home"))
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "profile\turl\tchain id\tpath")
+	for _, name := range profiles {
+		r, err := repo.New(ctx.String("home"), name)
+		if err != nil {
+			return err
+		}
+		cfg, err := r.LoadChainConfig()
+		if err != nil {
+			return err
+		}
+		chainID := "-"
+		if cfg.ChainID != 0 {
+			chainID = fmt.Sprint(cfg.ChainID)
+		}
+		url := cfg.URL
+		if url == "" {
+			url = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, url, chainID, r.Path)
+	}
+	return w.Flush()
+}
+
+func createProfile(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("create needs exactly one profile name")
+	}
+	r, err := repo.New(ctx.String("home"), ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if r.Profile != repo.DefaultProfile && r.Exists() {
+		return fmt.Errorf("profile %s already exists", r.Profile)
+	}
+	err = r.Init()
+	if err != nil {
+		return err
+	}
+	err = r.WriteChainConfig(&repo.ChainConfig{
+		URL:      ctx.String("chain-url"),
+		ChainID:  ctx.Uint64("chain-id"),
+		AdminKey: ctx.String("admin-key"),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("profile %s created at %s\n", r.Profile, r.Path)
+	return nil
+}
+
+func deleteProfile(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return fmt.Errorf("delete needs exactly one profile name")
+	}
+	r, err := repo.New(ctx.String("home"), ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if r.Profile == repo.DefaultProfile {
+		return fmt.Errorf("default profile can't be deleted")
+	}
+	if !r.Exists() {
+		return fmt.Errorf("profile %s not found", r.Profile)
+	}
+	if !ctx.Bool("yes") {
+		return fmt.Errorf("deleting profile %s removes its accounts, pass --yes to confirm", r.Profile)
+	}
+	err = os.RemoveAll(r.Path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("profile %s deleted\n", r.Profile)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+)
+
+// ReclaimAccountsBalance sends the balance of every account minus the fee
+// back to the admin or --to.
+func ReclaimAccountsBalance(ctx *cli.Context) error {
+	parallel := ctx.Int("parallel")
+	if parallel <= 0 {
+		return fmt.Errorf("parallel should be positive")
+	}
+	to := ctx.String("to")
+	if to != "" && !common.IsHexAddress(to) {
+		return fmt.Errorf("invalid address %s", to)
+	}
+
+	// init rpc client
+	err := initClient(ctx)
+	if err != nil {
+		return err
+	}
+	var receiver common.Address
+	if to != "" {
+		receiver = common.HexToAddress(to)
+	} else if utils.Admin() != nil {
+		receiver = utils.Admin().Address()
+	} else {
+		return fmt.Errorf("%w, use --to or --admin-key-env, --admin-keystore, --admin-signer or --dev", utils.ErrNoAdmin)
+	}
+
+	r, err := loadRepo(ctx)
+	if err != nil {
+		return err
+	}
+	store, err := IsInitAccounts(ctx)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	checkpoint, err := openCheckpoint(r.ReclaimPath(), "reclaim", store, ctx.Bool("resume"), parallel)
+	if err != nil {
+		return err
+	}
+	defer checkpoint.Close()
+
+	err = reclaim(store, checkpoint, receiver, parallel)
+	if err != nil {
+		return err
+	}
+	return checkpointSummary(checkpoint, "reclaim", "reclaimed")
+}
+
+// reclaim sweeps every account not confirmed yet to receiver, accounts
+// whose balance doesn't cover the fee are skipped.
+func reclaim(store repo.AccountStore, checkpoint *repo.Checkpoint, receiver common.Address, parallel int) error {
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("reclaiming %d accounts to %s\n", store.Len(), receiver)
+
+	var done, reclaimed int64
+	var mu sync.Mutex
+	total := new(big.Int)
+	stop := showProgress(func() string {
+		return fmt.Sprintf("%d/%d accounts checked, %d reclaimed", atomic.LoadInt64(&done), store.Len(), atomic.LoadInt64(&reclaimed))
+	})
+	parallelRange(0, store.Len(), parallel, func(i int) {
+		defer atomic.AddInt64(&done, 1)
+		status, _, err := checkpoint.Get(i)
+		if err == nil && status == repo.TxConfirmed {
+			return
+		}
+		account, err := store.Get(i)
+		if err != nil {
+			fmt.Printf("\nload account %d failed: %s\n", i, err)
+			markCheckpoint(checkpoint, i, repo.TxFailed, common.Hash{})
+			return
+		}
+		if account.Address == receiver {
+			markCheckpoint(checkpoint, i, repo.TxNone, common.Hash{})
+			return
+		}
+		value, fees, err := reclaimValue(pricer, account.Address, receiver)
+		if err != nil {
+			fmt.Printf("\nreclaim account %d failed: %s\n", i, err)
+			markCheckpoint(checkpoint, i, repo.TxFailed, common.Hash{})
+			return
+		}
+		if value.Sign() <= 0 {
+			// dust not worth the fee
+			markCheckpoint(checkpoint, i, repo.TxNone, common.Hash{})
+			return
+		}
+		key, err := account.PrivateKey()
+		var hash common.Hash
+		if err == nil {
+			hash, err = utils.SendTransfer(utils.NewKeySigner(key), receiver, value, fees)
+		}
+		if err != nil {
+			fmt.Printf("\nreclaim account %d failed: %s\n", i, err)
+			markCheckpoint(checkpoint, i, repo.TxFailed, common.Hash{})
+			return
+		}
+		markCheckpoint(checkpoint, i, repo.TxPending, hash)
+		err = utils.WaitReceipt(hash)
+		if errors.Is(err, utils.ErrReceiptTimeout) {
+			// may still be mined, left pending for --resume
+			fmt.Printf("\n%s\n", err)
+			return
+		}
+		if err != nil {
+			fmt.Printf("\nreclaim account %d failed: %s\n", i, err)
+			markCheckpoint(checkpoint, i, repo.TxFailed, hash)
+			return
+		}
+		markCheckpoint(checkpoint, i, repo.TxConfirmed, hash)
+		atomic.AddInt64(&reclaimed, 1)
+		mu.Lock()
+		total.Add(total, value)
+		mu.Unlock()
+	})
+	stop()
+	fmt.Printf("reclaimed %s wei from %d accounts\n", total, reclaimed)
+	return nil
+}
+
+// reclaimValue returns what address can send receiver after the most its
+// transfer may pay for gas, and the fees of the transfer.
+func reclaimValue(pricer *utils.GasPricer, address, receiver common.Address) (*big.Int, *utils.Fees, error) {
+	balance, err := utils.Balance(address)
+	if err != nil {
+		return nil, nil, err
+	}
+	gas, err := utils.TransferGasLimit(address, receiver, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	fees := pricer.Fees()
+	return balance.Sub(balance, fees.Cost(gas)), fees, nil
+}
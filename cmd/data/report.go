@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/axiomesh/data-producer/internal/report"
+	"github.com/urfave/cli/v2"
+)
+
+var reportCMD = &cli.Command{
+	Name:  "report",
+	Usage: "Run report tools",
+	Subcommands: []*cli.Command{
+		{
+			Name:      "diff",
+			Usage:     "Compare two run reports and flag performance regressions",
+			ArgsUsage: "<old.json> <new.json> [--threshold 10%]",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:  "threshold",
+					Usage: "Specify tolerated relative worsening before flagging a regression",
+					Value: "10%",
+				},
+			},
+			Action: diffReports,
+		},
+	},
+}
+
+func diffReports(ctx *cli.Context) error {
+	files, thresholdArg, err := diffArgs(ctx)
+	if err != nil {
+		return cli.Exit(err, 2)
+	}
+	threshold, err := report.ParseThreshold(thresholdArg)
+	if err != nil {
+		return cli.Exit(err, 2)
+	}
+	base, err := report.Load(files[0])
+	if err != nil {
+		return cli.Exit(err, 2)
+	}
+	target, err := report.Load(files[1])
+	if err != nil {
+		return cli.Exit(err, 2)
+	}
+
+	changes := report.Diff(base, target, threshold)
+	var regressions int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "method\tmetric\told\tnew\tdelta\t")
+	for _, c := range changes {
+		flag := ""
+		if c.Regression {
+			flag = "REGRESSION"
+			regressions++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.3f\t%.3f\t%+.2f%%\t%s\n", c.Method, c.Metric, c.Old, c.New, c.Delta*100, flag)
+	}
+	_ = w.Flush()
+
+	if regressions > 0 {
+		return cli.Exit(fmt.Sprintf("%d regression(s) over threshold %s", regressions, thresholdArg), 1)
+	}
+	return nil
+}
+
+// diffArgs returns the two reports and the threshold, which may also follow
+// the reports since flag parsing stops at the first argument.
+func diffArgs(ctx *cli.Context) ([]string, string, error) {
+	threshold := ctx.String("threshold")
+	var files []string
+	args := ctx.Args().Slice()
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || name != "threshold" {
+			files = append(files, args[i])
+			continue
+		}
+		if !hasValue {
+			if i+1 == len(args) {
+				return nil, "", fmt.Errorf("flag needs an argument: %s", args[i])
+			}
+			i++
+			value = args[i]
+		}
+		threshold = value
+	}
+	if len(files) != 2 {
+		return nil, "", fmt.Errorf("diff needs exactly two reports, got %d", len(files))
+	}
+	return files, threshold, nil
+}
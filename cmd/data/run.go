@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/axiomesh/data-producer/internal/model"
+	"github.com/axiomesh/data-producer/internal/report"
+	"github.com/axiomesh/data-producer/internal/runner"
+	"github.com/urfave/cli/v2"
+)
+
+var runCMD = &cli.Command{
+	Name:  "run",
+	Usage: "Replay generated testdata against the JSON RPC node",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "file",
+			Usage: "Specify generated testdata file",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Specify number of concurrent senders",
+			Value: DefaultParallel,
+		},
+		&cli.IntFlag{
+			Name:  "repeat",
+			Usage: "Specify how many times the testdata is replayed",
+			Value: 1,
+		},
+		&cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Specify open-loop arrival rate (req/s), used as start rate when stages are given",
+		},
+		&cli.DurationFlag{
+			Name:  "duration",
+			Usage: "Specify open-loop duration at constant rate",
+			Value: time.Minute,
+		},
+		&cli.StringFlag{
+			Name:  "stages",
+			Usage: "Specify open-loop ramp stages, e.g. 30s:1000,2m:5000,30s:0",
+		},
+		&cli.StringFlag{
+			Name:  "stages-file",
+			Usage: "Specify file with one duration:target ramp stage per line",
+		},
+		&cli.IntFlag{
+			Name:  "max-inflight",
+			Usage: "Specify max in-flight requests in open-loop mode, exceeding requests are dropped",
+			Value: 10000,
+		},
+		&cli.StringFlag{
+			Name:  "report",
+			Usage: "Specify report path, default is next to the testdata file",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Specify request timeout",
+			Value: 10 * time.Second,
+		},
+	},
+	Action: run,
+}
+
+func run(ctx *cli.Context) error {
+	concurrency := ctx.Int("concurrency")
+	if concurrency <= 0 {
+		return fmt.Errorf("concurrency should be positive")
+	}
+	repeat := ctx.Int("repeat")
+	if repeat <= 0 {
+		return fmt.Errorf("repeat should be positive")
+	}
+
+	if ctx.String("file") == "" {
+		return fmt.Errorf("file is required")
+	}
+	file, err := corpusPath(ctx, ctx.String("file"))
+	if err != nil {
+		return err
+	}
+	reqs, err := model.ReadReqs(file)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		return fmt.Errorf("no request found in %s", file)
+	}
+	url, err := rpcURL(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg := &runner.Config{
+		URL:         url,
+		Concurrency: concurrency,
+		Repeat:      repeat,
+		MaxInFlight: ctx.Int("max-inflight"),
+		Timeout:     ctx.Duration("timeout"),
+	}
+	profile, err := loadRateProfile(ctx)
+	if err != nil {
+		return err
+	}
+
+	var r *report.Report
+	if profile == nil {
+		r = runner.Run(cfg, reqs)
+	} else {
+		if cfg.MaxInFlight <= 0 {
+			return fmt.Errorf("max-inflight should be positive")
+		}
+		r = runner.RunOpenLoop(cfg, profile, reqs)
+	}
+	r.File = file
+	printReport(r)
+
+	reportPath := ctx.String("report")
+	if reportPath == "" {
+		base := strings.TrimSuffix(r.File, filepath.Ext(r.File))
+		reportPath = fmt.Sprintf("%s-report-%s.json", base, r.Start.Format("200601021504"))
+	}
+	err = r.Write(reportPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("report written to %s\n", reportPath)
+	return nil
+}
+
+// corpusPath resolves a relative file missing in the working directory
+// against the profile's corpora directory.
+func corpusPath(ctx *cli.Context, file string) (string, error) {
+	if _, err := os.Stat(file); err == nil || filepath.IsAbs(file) {
+		return file, nil
+	}
+	r, err := loadRepo(ctx)
+	if err != nil {
+		return "", err
+	}
+	inCorpora := filepath.Join(r.CorporaPath(), file)
+	if _, err := os.Stat(inCorpora); err == nil {
+		return inCorpora, nil
+	}
+	return file, nil
+}
+
+// loadRateProfile returns the open-loop load profile, or nil for closed-loop mode.
+func loadRateProfile(ctx *cli.Context) (*runner.Profile, error) {
+	rate := ctx.Float64("rate")
+	if rate < 0 {
+		return nil, fmt.Errorf("rate should not be negative")
+	}
+
+	var stages []runner.Stage
+	var err error
+	switch {
+	case ctx.IsSet("stages") && ctx.IsSet("stages-file"):
+		return nil, fmt.Errorf("stages and stages-file are exclusive")
+	case ctx.IsSet("stages"):
+		stages, err = runner.ParseStages(ctx.String("stages"))
+	case ctx.IsSet("stages-file"):
+		stages, err = runner.LoadStages(ctx.String("stages-file"))
+	case ctx.IsSet("rate"):
+		if ctx.Duration("duration") <= 0 {
+			return nil, fmt.Errorf("duration should be positive")
+		}
+		return runner.NewConstantProfile(rate, ctx.Duration("duration")), nil
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &runner.Profile{StartRate: rate, Stages: stages}, nil
+}
+
+func printReport(r *report.Report) {
+	fmt.Printf("mode:       %s\n", r.Mode)
+	fmt.Printf("requests:   %d\n", r.Requests)
+	fmt.Printf("success:    %d\n", r.Success)
+	fmt.Printf("errors:     %d\n", r.Errors)
+	if r.Dropped > 0 {
+		fmt.Printf("dropped:    %d\n", r.Dropped)
+	}
+	fmt.Printf("elapsed:    %.2fs\n", r.Elapsed)
+	fmt.Printf("throughput: %.2f req/s\n", r.Throughput)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\nmethod\trequests\terrors\treq/s\tp50(ms)\tp90(ms)\tp99(ms)\tp99.9(ms)\tmax(ms)")
+	printLatencyRow := func(name string, requests, errors int, throughput float64, l report.Latency) {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.2f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\n",
+			name, requests, errors, throughput, l.P50, l.P90, l.P99, l.P999, l.Max)
+	}
+	for _, name := range r.MethodNames() {
+		m := r.Methods[name]
+		printLatencyRow(name, m.Requests, m.Errors, m.Throughput, m.Latency)
+	}
+	printLatencyRow("total", r.Requests, r.Errors, r.Throughput, r.Latency)
+	_ = w.Flush()
+
+	if len(r.ErrorCodes) > 0 {
+		fmt.Println("\nerrors by code:")
+		codes := make([]string, 0, len(r.ErrorCodes))
+		for code := range r.ErrorCodes {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		for _, code := range codes {
+			fmt.Printf("  %s: %d (%s)\n", code, r.ErrorCodes[code], r.Messages[code])
+		}
+	}
+}
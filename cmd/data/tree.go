@@ -0,0 +1,298 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/axiomesh/data-producer/internal/repo"
+	"github.com/axiomesh/data-producer/internal/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// fundTree funds accounts along a tree in heap layout: the admin funds
+// accounts [0, fanout) and account i funds accounts [fanout*(i+1),
+// fanout*(i+1)+fanout), every account forwarding what its subtree needs, so
+// the number of funding rounds grows logarithmically with the accounts.
+// values are what every account keeps for itself. Accounts confirmed by an
+// earlier run aren't funded again, they forward what their unfunded subtree
+// needs now while keeping at least keep, topped up by the admin when short
+// since the subtree's amounts are drawn anew and the fees they paid are gone.
+func fundTree(store repo.AccountStore, checkpoint *repo.Checkpoint, values []*big.Int, keep *big.Int, fanout, parallel int) error {
+	quantity := store.Len()
+	pricer, err := utils.NewGasPricer()
+	if err != nil {
+		return err
+	}
+	first, err := store.Get(0)
+	if err != nil {
+		return err
+	}
+	gas, err := utils.TransferGasLimit(utils.Admin().Address(), first.Address, values[0])
+	if err != nil {
+		return err
+	}
+	// every transfer is budgeted at the highest fees of the strategy
+	fee := pricer.MaxFees().Cost(gas)
+
+	held := make([]bool, quantity)
+	for i := range held {
+		status, _, err := checkpoint.Get(i)
+		held[i] = err == nil && status == repo.TxConfirmed
+	}
+	// what every subtree needs, children before parents
+	need := make([]*big.Int, quantity)
+	var total int64
+	for i := quantity - 1; i >= 0; i-- {
+		need[i] = new(big.Int).Set(values[i])
+		for _, child := range treeChildren(i, fanout, quantity) {
+			if need[child].Sign() > 0 && !held[child] {
+				need[i].Add(need[i], need[child])
+				need[i].Add(need[i], fee)
+			}
+		}
+		if need[i].Sign() > 0 && !held[i] {
+			total++
+		}
+	}
+
+	var levels [][2]int
+	for start, width := 0, fanout; start < quantity; start, width = start+width, width*fanout {
+		end := start + width
+		if end > quantity {
+			end = quantity
+		}
+		levels = append(levels, [2]int{start, end})
+	}
+	fmt.Printf("funding %d accounts in %d levels with fanout %d\n", total, len(levels), fanout)
+
+	var funded, level int64
+	stop := showProgress(func() string {
+		return fmt.Sprintf("level %d/%d, %d/%d accounts funded", atomic.LoadInt64(&level), len(levels), atomic.LoadInt64(&funded), total)
+	})
+
+	// failed accounts weren't funded and fail their subtree, pending ones
+	// may still be mined
+	failed := make([]bool, quantity)
+	pending := make([]bool, quantity)
+	hashes := make([]common.Hash, quantity)
+	fail := func(children []int) {
+		for _, child := range children {
+			if held[child] {
+				continue
+			}
+			failed[child] = true
+			if need[child].Sign() > 0 {
+				markCheckpoint(checkpoint, child, repo.TxFailed, common.Hash{})
+			}
+		}
+	}
+	confirm := func(child int) {
+		err := utils.WaitReceipt(hashes[child])
+		if errors.Is(err, utils.ErrReceiptTimeout) {
+			pending[child] = true
+			return
+		}
+		pending[child] = false
+		if err != nil {
+			fmt.Printf("\nfund account %d failed: %s\n", child, err)
+			failed[child] = true
+			markCheckpoint(checkpoint, child, repo.TxFailed, hashes[child])
+			return
+		}
+		markCheckpoint(checkpoint, child, repo.TxConfirmed, hashes[child])
+		atomic.AddInt64(&funded, 1)
+	}
+	fund := func(from utils.Signer, children []int) {
+		for _, child := range children {
+			if need[child].Sign() == 0 || held[child] {
+				continue
+			}
+			account, err := store.Get(child)
+			if err == nil {
+				hashes[child], err = utils.SendTransfer(from, account.Address, need[child], pricer.Fees())
+			}
+			if err != nil {
+				fmt.Printf("\nfund account %d failed: %s\n", child, err)
+				fail([]int{child})
+				continue
+			}
+			markCheckpoint(checkpoint, child, repo.TxPending, hashes[child])
+		}
+		for _, child := range children {
+			if need[child].Sign() == 0 || held[child] || failed[child] {
+				continue
+			}
+			confirm(child)
+		}
+	}
+
+	refill := func(address common.Address, need *big.Int) error {
+		balance, err := utils.Balance(address)
+		if err != nil {
+			return err
+		}
+		short := new(big.Int).Add(need, keep)
+		short.Sub(short, balance)
+		if short.Sign() <= 0 {
+			return nil
+		}
+		hash, err := utils.SendTransfer(utils.Admin(), address, short, pricer.Fees())
+		if err != nil {
+			return err
+		}
+		return utils.WaitReceipt(hash)
+	}
+
+	atomic.StoreInt64(&level, 1)
+	root := make([]int, 0, fanout)
+	for i := levels[0][0]; i < levels[0][1]; i++ {
+		root = append(root, i)
+	}
+	fund(utils.Admin(), root)
+
+	for l := 1; l < len(levels); l++ {
+		atomic.StoreInt64(&level, int64(l+1))
+		parallelRange(levels[l-1][0], levels[l-1][1], parallel, func(parent int) {
+			children := treeChildren(parent, fanout, quantity)
+			if pending[parent] {
+				// give it another timeout before leaving its subtree
+				confirm(parent)
+			}
+			if pending[parent] {
+				fmt.Printf("\naccount %d not mined yet, its subtree is left for --resume\n", parent)
+				fail(children)
+				return
+			}
+			if failed[parent] {
+				fail(children)
+				return
+			}
+			if need[parent].Cmp(values[parent]) == 0 {
+				// nothing to forward
+				return
+			}
+			account, err := store.Get(parent)
+			if err != nil {
+				fmt.Printf("\nload account %d failed: %s\n", parent, err)
+				fail(children)
+				return
+			}
+			if held[parent] {
+				err = refill(account.Address, need[parent])
+				if err != nil {
+					fmt.Printf("\ntop up account %d failed, its subtree is left for --resume: %s\n", parent, err)
+					fail(children)
+					return
+				}
+			}
+			key, err := account.PrivateKey()
+			if err == nil {
+				fund(utils.NewKeySigner(key), children)
+				return
+			}
+			fmt.Printf("\naccount %d can't fund its subtree: %s\n", parent, err)
+			fail(children)
+		})
+	}
+	stop()
+	return nil
+}
+
+// showProgress prints the progress line every second until stopped, and
+// once more when stopped.
+func showProgress(line func() string) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				fmt.Printf("\r%s\n", line())
+				return
+			case <-ticker.C:
+				fmt.Printf("\r%s", line())
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+// treeChildren returns the accounts funded by account i.
+func treeChildren(i, fanout, quantity int) []int {
+	start := fanout * (i + 1)
+	if start >= quantity {
+		return nil
+	}
+	end := start + fanout
+	if end > quantity {
+		end = quantity
+	}
+	children := make([]int, 0, end-start)
+	for c := start; c < end; c++ {
+		children = append(children, c)
+	}
+	return children
+}
+
+// verifyBalances checks every account holds at least its target.
+func verifyBalances(store repo.AccountStore, targets []*big.Int, parallel int) error {
+	var underfunded int64
+	first := -1
+	var mu sync.Mutex
+	parallelRange(0, store.Len(), parallel, func(i int) {
+		account, err := store.Get(i)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		balance, err := utils.Balance(account.Address)
+		if err == nil && balance.Cmp(targets[i]) >= 0 {
+			return
+		}
+		atomic.AddInt64(&underfunded, 1)
+		mu.Lock()
+		if first == -1 || i < first {
+			first = i
+		}
+		mu.Unlock()
+	})
+	if underfunded > 0 {
+		account, err := store.Get(first)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("verify failed, %d of %d accounts underfunded, first is account %d %s", underfunded, store.Len(), first, account.Address)
+	}
+	fmt.Printf("verified %d accounts\n", store.Len())
+	return nil
+}
+
+// parallelRange runs fn for [start, end) on parallel goroutines.
+func parallelRange(start, end, parallel int, fn func(i int)) {
+	ch := make(chan int, parallel)
+	wg := sync.WaitGroup{}
+	wg.Add(parallel)
+	for i := 0; i < parallel; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range ch {
+				fn(j)
+			}
+		}()
+	}
+	for i := start; i < end; i++ {
+		ch <- i
+	}
+	close(ch)
+	wg.Wait()
+}
@@ -0,0 +1,36 @@
+package hdwallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDeriveBIP44(t *testing.T) {
+	seed, err := SeedFromMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := NewMaster(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := accounts.ParseDerivationPath(DefaultBasePath + "/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := master.Derive(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := key.PrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+	if got := crypto.PubkeyToAddress(priv.PublicKey); got != want {
+		t.Errorf("got address %s, want %s", got, want)
+	}
+}
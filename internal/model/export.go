@@ -0,0 +1,181 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// VegetaWriter writes requests as vegeta json targets, run with
+// `vegeta attack -format=json -targets=<file>`.
+type VegetaWriter struct {
+	*rowFile
+	url     string
+	encoder *json.Encoder
+}
+
+type vegetaTarget struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Body   []byte      `json:"body"`
+	Header http.Header `json:"header"`
+}
+
+func NewVegetaWriter(filename, url string) (*VegetaWriter, error) {
+	f, err := createRowFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &VegetaWriter{
+		rowFile: f,
+		url:     url,
+		encoder: json.NewEncoder(&f.buf),
+	}, nil
+}
+
+func (w *VegetaWriter) Write(req *EthReq) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	err = w.encoder.Encode(&vegetaTarget{
+		Method: http.MethodPost,
+		URL:    w.url,
+		Body:   body,
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+	})
+	if err != nil {
+		return err
+	}
+	w.count++
+	return w.rowDone()
+}
+
+const k6Header = `import http from 'k6/http';
+import exec from 'k6/execution';
+import { SharedArray } from 'k6/data';
+
+const url = %s;
+const params = { headers: { 'Content-Type': 'application/json' } };
+
+const bodies = new SharedArray('bodies', function () {
+  return [
+`
+
+const k6Footer = `  ];
+});
+
+export default function () {
+  http.post(url, bodies[exec.scenario.iterationInTest %% bodies.length], params);
+}
+`
+
+// K6Writer writes requests as a k6 script, run with `k6 run --vus <n> --duration <d> <file>`.
+type K6Writer struct {
+	*rowFile
+}
+
+func NewK6Writer(filename, url string) (*K6Writer, error) {
+	f, err := createRowFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	quoted, err := json.Marshal(url)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	fmt.Fprintf(&f.buf, k6Header, quoted)
+	return &K6Writer{rowFile: f}, nil
+}
+
+func (w *K6Writer) Write(req *EthReq) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	// a JSON string is a valid JavaScript string literal
+	quoted, err := json.Marshal(string(body))
+	if err != nil {
+		return err
+	}
+	w.buf.WriteString("    ")
+	w.buf.Write(quoted)
+	w.buf.WriteString(",\n")
+	w.count++
+	return w.rowDone()
+}
+
+func (w *K6Writer) Close() error {
+	fmt.Fprintf(&w.buf, k6Footer)
+	return w.rowFile.Close()
+}
+
+// wrkChunkSize bounds the bodies per Lua function, LuaJIT limits the
+// constants of a single function.
+const wrkChunkSize = 10000
+
+const wrkHeader = `-- run with: wrk -s <file> %s
+local chunks = {}
+`
+
+const wrkFooter = `
+local bodies = {}
+for _, chunk in ipairs(chunks) do
+  for _, body in ipairs(chunk()) do
+    bodies[#bodies + 1] = body
+  end
+end
+
+local counter = 0
+wrk.method = "POST"
+wrk.headers["Content-Type"] = "application/json"
+
+request = function()
+  counter = counter + 1
+  return wrk.format(nil, nil, nil, bodies[(counter - 1) %% #bodies + 1])
+end
+`
+
+// WrkWriter writes requests as a wrk lua script.
+type WrkWriter struct {
+	*rowFile
+}
+
+func NewWrkWriter(filename, url string) (*WrkWriter, error) {
+	f, err := createRowFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&f.buf, wrkHeader, url)
+	return &WrkWriter{rowFile: f}, nil
+}
+
+var luaEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`)
+
+func (w *WrkWriter) Write(req *EthReq) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if w.count%wrkChunkSize == 0 {
+		if w.count > 0 {
+			w.buf.WriteString("} end\n")
+		}
+		w.buf.WriteString("chunks[#chunks + 1] = function() return {\n")
+	}
+	w.buf.WriteString(`  "`)
+	w.buf.WriteString(luaEscaper.Replace(string(body)))
+	w.buf.WriteString("\",\n")
+	w.count++
+	return w.rowDone()
+}
+
+func (w *WrkWriter) Close() error {
+	if w.count > 0 {
+		w.buf.WriteString("} end\n")
+	}
+	fmt.Fprintf(&w.buf, wrkFooter)
+	return w.rowFile.Close()
+}
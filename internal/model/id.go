@@ -0,0 +1,88 @@
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+)
+
+const (
+	IDSchemeSequential = "sequential"
+	IDSchemeUUID       = "uuid"
+)
+
+// ReqID is a JSON-RPC request id, kept as its JSON encoding so both number
+// and string ids survive csv and json round trips unchanged.
+type ReqID string
+
+func NumberID(id uint64) ReqID {
+	return ReqID(strconv.FormatUint(id, 10))
+}
+
+func StringID(id string) ReqID {
+	bytes, _ := json.Marshal(id)
+	return ReqID(bytes)
+}
+
+func (id ReqID) MarshalJSON() ([]byte, error) {
+	if id == "" {
+		return []byte("null"), nil
+	}
+	return []byte(id), nil
+}
+
+func (id *ReqID) UnmarshalJSON(data []byte) error {
+	var buf bytes.Buffer
+	err := json.Compact(&buf, data)
+	if err != nil {
+		return err
+	}
+	*id = ReqID(buf.String())
+	return nil
+}
+
+func (id ReqID) MarshalCSV() (string, error) {
+	return string(id), nil
+}
+
+func (id *ReqID) UnmarshalCSV(csv string) error {
+	return id.UnmarshalJSON([]byte(csv))
+}
+
+type IDGenerator interface {
+	Next() ReqID
+}
+
+func NewIDGenerator(scheme string) (IDGenerator, error) {
+	switch scheme {
+	case IDSchemeSequential:
+		return &sequentialID{}, nil
+	case IDSchemeUUID:
+		return &uuidID{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported id scheme %q", scheme)
+	}
+}
+
+// sequentialID assigns numbers starting from 1.
+type sequentialID struct {
+	last uint64
+}
+
+func (g *sequentialID) Next() ReqID {
+	return NumberID(atomic.AddUint64(&g.last, 1))
+}
+
+// uuidID assigns random version 4 uuid strings.
+type uuidID struct{}
+
+func (g *uuidID) Next() ReqID {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return StringID(fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]))
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Manifest describes a generated corpus, it is written next to the corpus
+// file with a .meta.json suffix.
+type Manifest struct {
+	File     string         `json:"file"`
+	Method   string         `json:"method"`
+	Format   string         `json:"format"`
+	IDScheme string         `json:"id_scheme"`
+	Weights  map[string]int `json:"weights,omitempty"`
+	Count    int            `json:"count"`
+	URL      string         `json:"url"`
+	Created  time.Time      `json:"created"`
+}
+
+func ManifestPath(filename string) string {
+	return filename + ".meta.json"
+}
+
+func (m *Manifest) Write(path string) error {
+	bytes, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0644)
+}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"encoding/json"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/ethereum/go-ethereum"
 
@@ -21,8 +23,12 @@ func (params *Params) MarshalCSV() (string, error) {
 	return string(bytes), err
 }
 
+func (params *Params) UnmarshalCSV(csv string) error {
+	return json.Unmarshal([]byte(csv), params)
+}
+
 type EthReq struct {
-	ID      int    `json:"id" csv:"id"`
+	ID      ReqID  `json:"id" csv:"id"`
 	JsonRpc string `json:"jsonrpc" csv:"jsonrpc"`
 	Method  string `json:"method" csv:"method"`
 	Params  Params `json:"params" csv:"params"`
@@ -30,7 +36,7 @@ type EthReq struct {
 
 func NewEthReq(method string, params ...interface{}) *EthReq {
 	return &EthReq{
-		ID:      1,
+		ID:      NumberID(1),
 		JsonRpc: "2.0",
 		Method:  method,
 		Params:  params,
@@ -136,15 +142,50 @@ func NewSendRawTransactionReq(rawTx string) *EthReq {
 	return NewEthReq(method, rawTx)
 }
 
-func CreateAndWriteReqs(filename string, req []*EthReq) error {
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, os.ModePerm)
+func CreateAndWriteReqs(filename string, reqs []*EthReq) error {
+	writer, err := NewCSVWriter(filename)
 	if err != nil {
 		return err
 	}
-	writer := gocsv.DefaultCSVWriter(file)
-	err = gocsv.MarshalCSV(req, writer)
+	for _, req := range reqs {
+		err = writer.Write(req)
+		if err != nil {
+			_ = writer.Close()
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// ReadReqs reads requests written in csv or, for files with a .jsonl
+// extension, jsonl format.
+func ReadReqs(filename string) ([]*EthReq, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reqs []*EthReq
+	if filepath.Ext(filename) == "."+FormatJSONL {
+		decoder := json.NewDecoder(file)
+		for {
+			var req EthReq
+			err = decoder.Decode(&req)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			reqs = append(reqs, &req)
+		}
+		return reqs, nil
+	}
+
+	err = gocsv.UnmarshalFile(file, &reqs)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
+	return reqs, nil
 }
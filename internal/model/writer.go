@@ -0,0 +1,178 @@
+package model
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSONL  = "jsonl"
+	FormatVegeta = "vegeta"
+	FormatK6     = "k6"
+	FormatWrk    = "wrk"
+)
+
+// flushSize is the buffered size after which rows are written to the file.
+// Rows are only ever written whole, so an interrupted file stays valid.
+const flushSize = 64 * 1024
+
+type ReqWriter interface {
+	Write(req *EthReq) error
+	Count() int
+	Close() error
+}
+
+// NewReqWriter creates a writer for format, url is the JSON RPC url
+// embedded by the load tool formats.
+func NewReqWriter(format, filename, url string) (ReqWriter, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(filename)
+	case FormatJSONL:
+		return NewJSONLWriter(filename)
+	case FormatVegeta:
+		return NewVegetaWriter(filename, url)
+	case FormatK6:
+		return NewK6Writer(filename, url)
+	case FormatWrk:
+		return NewWrkWriter(filename, url)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// FormatExt returns the file extension of format.
+func FormatExt(format string) string {
+	switch format {
+	case FormatVegeta:
+		return "json"
+	case FormatK6:
+		return "js"
+	case FormatWrk:
+		return "lua"
+	default:
+		return format
+	}
+}
+
+// rowFile buffers whole rows and writes them to the file in batches.
+type rowFile struct {
+	file  *os.File
+	buf   bytes.Buffer
+	count int
+}
+
+func createRowFile(filename string) (*rowFile, error) {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &rowFile{file: file}, nil
+}
+
+func (f *rowFile) rowDone() error {
+	if f.buf.Len() >= flushSize {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (f *rowFile) Flush() error {
+	_, err := f.file.Write(f.buf.Bytes())
+	f.buf.Reset()
+	return err
+}
+
+func (f *rowFile) Count() int {
+	return f.count
+}
+
+func (f *rowFile) Close() error {
+	err := f.Flush()
+	if err != nil {
+		_ = f.file.Close()
+		return err
+	}
+	return f.file.Close()
+}
+
+type CSVWriter struct {
+	*rowFile
+	csv *csv.Writer
+}
+
+func NewCSVWriter(filename string) (*CSVWriter, error) {
+	f, err := createRowFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	w := &CSVWriter{
+		rowFile: f,
+		csv:     csv.NewWriter(&f.buf),
+	}
+	err = w.writeRecord([]string{"id", "jsonrpc", "method", "params"})
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *CSVWriter) Write(req *EthReq) error {
+	params, err := req.Params.MarshalCSV()
+	if err != nil {
+		return err
+	}
+	id, err := req.ID.MarshalCSV()
+	if err != nil {
+		return err
+	}
+	err = w.writeRecord([]string{id, req.JsonRpc, req.Method, params})
+	if err != nil {
+		return err
+	}
+	w.count++
+	return nil
+}
+
+func (w *CSVWriter) writeRecord(record []string) error {
+	err := w.csv.Write(record)
+	if err != nil {
+		return err
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.rowDone()
+}
+
+// JSONLWriter writes every request as a complete JSON-RPC request body per line.
+type JSONLWriter struct {
+	*rowFile
+	encoder *json.Encoder
+}
+
+func NewJSONLWriter(filename string) (*JSONLWriter, error) {
+	f, err := createRowFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLWriter{
+		rowFile: f,
+		encoder: json.NewEncoder(&f.buf),
+	}, nil
+}
+
+func (w *JSONLWriter) Write(req *EthReq) error {
+	err := w.encoder.Encode(req)
+	if err != nil {
+		return err
+	}
+	w.count++
+	return w.rowDone()
+}
@@ -0,0 +1,146 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Checkpoint layout: a header of magic, account count and the first
+// account's address, followed by a fixed size record of status and tx
+// hash per account, updated in place as transactions progress.
+const (
+	checkpointMagic      = "DPCKPT01"
+	checkpointHeaderSize = 16 + common.AddressLength
+	checkpointRecordSize = 1 + common.HashLength
+)
+
+type TxStatus byte
+
+const (
+	TxNone TxStatus = iota
+	TxPending
+	TxConfirmed
+	TxFailed
+)
+
+func (s TxStatus) String() string {
+	switch s {
+	case TxPending:
+		return "pending"
+	case TxConfirmed:
+		return "confirmed"
+	case TxFailed:
+		return "failed"
+	}
+	return "none"
+}
+
+// Checkpoint persists the transaction of every account, so that a run
+// sending one transaction per account can be resumed.
+type Checkpoint struct {
+	file  *os.File
+	count int
+}
+
+// CreateCheckpoint creates an empty checkpoint for the accounts of store,
+// replacing any existing one.
+func CreateCheckpoint(path string, store AccountStore) (*Checkpoint, error) {
+	first, err := checkpointOwner(store)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, checkpointHeaderSize)
+	copy(header, checkpointMagic)
+	binary.LittleEndian.PutUint64(header[8:16], uint64(store.Len()))
+	copy(header[16:], first.Bytes())
+	_, err = file.Write(header)
+	if err == nil {
+		err = file.Truncate(int64(checkpointHeaderSize + store.Len()*checkpointRecordSize))
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &Checkpoint{file: file, count: store.Len()}, nil
+}
+
+// OpenCheckpoint opens the checkpoint at path, it must belong to the
+// accounts of store.
+func OpenCheckpoint(path string, store AccountStore) (*Checkpoint, error) {
+	first, err := checkpointOwner(store)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, checkpointHeaderSize)
+	_, err = file.ReadAt(header, 0)
+	if err != nil || !bytes.Equal(header[:8], []byte(checkpointMagic)) {
+		_ = file.Close()
+		return nil, fmt.Errorf("invalid checkpoint %s", path)
+	}
+	count := binary.LittleEndian.Uint64(header[8:16])
+	if count != uint64(store.Len()) || !bytes.Equal(header[16:], first.Bytes()) {
+		_ = file.Close()
+		return nil, fmt.Errorf("checkpoint %s belongs to other accounts", path)
+	}
+	return &Checkpoint{file: file, count: int(count)}, nil
+}
+
+func checkpointOwner(store AccountStore) (common.Address, error) {
+	if store.Len() == 0 {
+		return common.Address{}, nil
+	}
+	account, err := store.Get(0)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return account.Address, nil
+}
+
+func (c *Checkpoint) Len() int {
+	return c.count
+}
+
+func (c *Checkpoint) Get(i int) (TxStatus, common.Hash, error) {
+	if i < 0 || i >= c.count {
+		return TxNone, common.Hash{}, fmt.Errorf("checkpoint index %d out of range [0, %d)", i, c.count)
+	}
+	record := make([]byte, checkpointRecordSize)
+	_, err := c.file.ReadAt(record, int64(checkpointHeaderSize+i*checkpointRecordSize))
+	if err != nil {
+		return TxNone, common.Hash{}, err
+	}
+	return TxStatus(record[0]), common.BytesToHash(record[1:]), nil
+}
+
+// Set records the status and tx hash of account i, safe for concurrent
+// use on different accounts.
+func (c *Checkpoint) Set(i int, status TxStatus, hash common.Hash) error {
+	if i < 0 || i >= c.count {
+		return fmt.Errorf("checkpoint index %d out of range [0, %d)", i, c.count)
+	}
+	record := make([]byte, checkpointRecordSize)
+	record[0] = byte(status)
+	copy(record[1:], hash.Bytes())
+	_, err := c.file.WriteAt(record, int64(checkpointHeaderSize+i*checkpointRecordSize))
+	return err
+}
+
+func (c *Checkpoint) Close() error {
+	err := c.file.Sync()
+	if closeErr := c.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
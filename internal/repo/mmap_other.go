@@ -0,0 +1,13 @@
+//go:build !unix
+
+package repo
+
+import "os"
+
+func mmapFile(path string) ([]byte, func() error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
@@ -1,30 +1,246 @@
 package repo
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/mitchellh/go-homedir"
 )
 
 const (
-	DefaultDirPath  = "~/.data_producer/"
-	DefaultAccounts = DefaultDirPath + "accounts"
+	DefaultDirPath = "~/.data_producer/"
+	DefaultProfile = "default"
+
+	AccountsFile          = "accounts"
+	BinaryAccountsFile    = "accounts.bin"
+	EncryptedAccountsFile = "accounts.enc"
+	ChainConfigFile       = "chain.json"
+	FundingFile           = "funding.bin"
+	ReclaimFile           = "reclaim.bin"
+	CorporaDir            = "corpora"
+	ProfilesDir           = "profiles"
 )
 
-func DirPath() (string, error) {
-	return homedir.Expand(DefaultDirPath)
+var profileName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// Repo is the directory of a profile. The default profile lives in the
+// home directory itself, named profiles under home/profiles/<name>.
+type Repo struct {
+	Home    string
+	Profile string
+	Path    string
+}
+
+func New(home, profile string) (*Repo, error) {
+	if home == "" {
+		home = DefaultDirPath
+	}
+	home, err := homedir.Expand(home)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		profile = DefaultProfile
+	}
+	if !profileName.MatchString(profile) {
+		return nil, fmt.Errorf("invalid profile name %q", profile)
+	}
+	path := home
+	if profile != DefaultProfile {
+		path = filepath.Join(home, ProfilesDir, profile)
+	}
+	return &Repo{Home: home, Profile: profile, Path: path}, nil
+}
+
+func (r *Repo) Exists() bool {
+	info, err := os.Stat(r.Path)
+	return err == nil && info.IsDir()
+}
+
+func (r *Repo) Init() error {
+	return os.MkdirAll(r.Path, 0755)
+}
+
+// AccountsPath is the legacy text accounts file.
+func (r *Repo) AccountsPath() string {
+	return filepath.Join(r.Path, AccountsFile)
+}
+
+func (r *Repo) BinaryAccountsPath() string {
+	return filepath.Join(r.Path, BinaryAccountsFile)
+}
+
+func (r *Repo) EncryptedAccountsPath() string {
+	return filepath.Join(r.Path, EncryptedAccountsFile)
+}
+
+// FundingPath is the checkpoint of balance init.
+func (r *Repo) FundingPath() string {
+	return filepath.Join(r.Path, FundingFile)
+}
+
+// ReclaimPath is the checkpoint of balance reclaim.
+func (r *Repo) ReclaimPath() string {
+	return filepath.Join(r.Path, ReclaimFile)
+}
+
+func (r *Repo) ChainConfigPath() string {
+	return filepath.Join(r.Path, ChainConfigFile)
+}
+
+// ConfigPath returns the profile's config file, config.yaml, config.yml or
+// config.toml, or "" when there is none.
+func (r *Repo) ConfigPath() string {
+	for _, name := range []string{"config.yaml", "config.yml", "config.toml"} {
+		path := filepath.Join(r.Path, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+func (r *Repo) CorporaPath() string {
+	return filepath.Join(r.Path, CorporaDir)
+}
+
+// ChainConfig is the chain a profile targets, zero values fall back to
+// command line flags and node queries.
+type ChainConfig struct {
+	URL      string `json:"url,omitempty"`
+	ChainID  uint64 `json:"chain_id,omitempty"`
+	AdminKey string `json:"admin_key,omitempty"`
+}
+
+func (r *Repo) LoadChainConfig() (*ChainConfig, error) {
+	bytes, err := os.ReadFile(r.ChainConfigPath())
+	if os.IsNotExist(err) {
+		return &ChainConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg ChainConfig
+	err = json.Unmarshal(bytes, &cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (r *Repo) WriteChainConfig(cfg *ChainConfig) error {
+	bytes, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.ChainConfigPath(), bytes, 0600)
 }
 
-func AccountsPath() (string, error) {
-	return homedir.Expand(DefaultAccounts)
+// ListProfiles returns the profiles under home, the default profile first.
+func ListProfiles(home string) ([]string, error) {
+	r, err := New(home, DefaultProfile)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []string{DefaultProfile}
+	entries, err := os.ReadDir(filepath.Join(r.Home, ProfilesDir))
+	if os.IsNotExist(err) {
+		return profiles, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileName.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...), nil
 }
 
+// LoadAccounts reads the legacy text accounts file.
 func LoadAccounts(path string) ([]string, error) {
 	bytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
+	return splitAccounts(bytes), nil
+}
+
+func splitAccounts(bytes []byte) []string {
 	accounts := strings.Split(string(bytes), "\n")
-	return accounts[:len(accounts)-1], nil
+	return accounts[:len(accounts)-1]
+}
+
+// encryptedAccounts is the binary accounts store (or the legacy text
+// accounts) encrypted as a whole with Web3 Secret Storage crypto, Count is
+// kept in clear to check the quantity without the passphrase.
+type encryptedAccounts struct {
+	Version int                 `json:"version"`
+	Count   int                 `json:"count"`
+	Crypto  keystore.CryptoJSON `json:"crypto"`
+}
+
+func WriteEncryptedAccounts(path string, accounts []*Account, passphrase string) error {
+	crypto, err := keystore.EncryptDataV3(EncodeAccounts(accounts), []byte(passphrase), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return err
+	}
+	bytes, err := json.Marshal(&encryptedAccounts{
+		Version: 2,
+		Count:   len(accounts),
+		Crypto:  crypto,
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0600)
+}
+
+func LoadEncryptedAccounts(path string, passphrase string) (AccountStore, error) {
+	enc, err := readEncryptedAccounts(path)
+	if err != nil {
+		return nil, err
+	}
+	bytes, err := keystore.DecryptDataV3(enc.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if IsBinaryAccounts(bytes) {
+		return NewMemoryStore(bytes)
+	}
+	accounts, err := TextToAccounts(splitAccounts(bytes))
+	if err != nil {
+		return nil, err
+	}
+	return NewMemoryStore(EncodeAccounts(accounts))
+}
+
+func EncryptedAccountsCount(path string) (int, error) {
+	enc, err := readEncryptedAccounts(path)
+	if err != nil {
+		return 0, err
+	}
+	return enc.Count, nil
+}
+
+func readEncryptedAccounts(path string) (*encryptedAccounts, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var enc encryptedAccounts
+	err = json.Unmarshal(bytes, &enc)
+	if err != nil {
+		return nil, err
+	}
+	return &enc, nil
 }
@@ -0,0 +1,181 @@
+package repo
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Binary account store layout: a header of magic and account count,
+// followed by fixed size records of index, private key and address, so
+// the i-th account is read in O(1) at headerSize + i*recordSize.
+const (
+	storeMagic = "DPACCT01"
+	headerSize = 16
+	recordSize = 8 + 32 + common.AddressLength
+)
+
+type Account struct {
+	Index   uint64
+	Key     [32]byte
+	Address common.Address
+}
+
+func NewAccount(index int, key *ecdsa.PrivateKey) *Account {
+	account := &Account{
+		Index:   uint64(index),
+		Address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+	key.D.FillBytes(account.Key[:])
+	return account
+}
+
+func (a *Account) PrivateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(a.Key[:])
+}
+
+// Hex returns the hex private key.
+func (a *Account) Hex() string {
+	return common.Bytes2Hex(a.Key[:])
+}
+
+type AccountStore interface {
+	Len() int
+	Get(i int) (*Account, error)
+	// Range returns accounts in [start, end).
+	Range(start, end int) ([]*Account, error)
+	Close() error
+}
+
+// BinaryStore reads accounts from the binary layout, data is either
+// memory-mapped from a file or held in memory.
+type BinaryStore struct {
+	data  []byte
+	count int
+	close func() error
+}
+
+func newBinaryStore(data []byte, close func() error) (*BinaryStore, error) {
+	if !IsBinaryAccounts(data) {
+		return nil, fmt.Errorf("invalid binary accounts store")
+	}
+	count := binary.LittleEndian.Uint64(data[8:headerSize])
+	if uint64(len(data)-headerSize) != count*recordSize {
+		return nil, fmt.Errorf("binary accounts store is truncated, expect %d accounts", count)
+	}
+	return &BinaryStore{data: data, count: int(count), close: close}, nil
+}
+
+// NewMemoryStore decodes a binary accounts store held in memory.
+func NewMemoryStore(data []byte) (*BinaryStore, error) {
+	return newBinaryStore(data, nil)
+}
+
+// OpenBinaryStore memory-maps the binary accounts store at path where supported.
+func OpenBinaryStore(path string) (*BinaryStore, error) {
+	data, closeFn, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	store, err := newBinaryStore(data, closeFn)
+	if err != nil {
+		_ = closeFn()
+		return nil, err
+	}
+	return store, nil
+}
+
+func IsBinaryAccounts(data []byte) bool {
+	return len(data) >= headerSize && bytes.Equal(data[:8], []byte(storeMagic))
+}
+
+func (s *BinaryStore) Len() int {
+	return s.count
+}
+
+func (s *BinaryStore) Get(i int) (*Account, error) {
+	if i < 0 || i >= s.count {
+		return nil, fmt.Errorf("account index %d out of range [0, %d)", i, s.count)
+	}
+	record := s.data[headerSize+i*recordSize : headerSize+(i+1)*recordSize]
+	account := &Account{Index: binary.LittleEndian.Uint64(record[:8])}
+	copy(account.Key[:], record[8:40])
+	copy(account.Address[:], record[40:])
+	return account, nil
+}
+
+func (s *BinaryStore) Range(start, end int) ([]*Account, error) {
+	if start < 0 || end > s.count || start > end {
+		return nil, fmt.Errorf("account range [%d, %d) out of range [0, %d)", start, end, s.count)
+	}
+	accounts := make([]*Account, 0, end-start)
+	for i := start; i < end; i++ {
+		account, err := s.Get(i)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+func (s *BinaryStore) Close() error {
+	if s.close == nil {
+		return nil
+	}
+	return s.close()
+}
+
+func EncodeAccounts(accounts []*Account) []byte {
+	data := make([]byte, headerSize+len(accounts)*recordSize)
+	copy(data, storeMagic)
+	binary.LittleEndian.PutUint64(data[8:headerSize], uint64(len(accounts)))
+	for i, account := range accounts {
+		record := data[headerSize+i*recordSize:]
+		binary.LittleEndian.PutUint64(record[:8], account.Index)
+		copy(record[8:40], account.Key[:])
+		copy(record[40:recordSize], account.Address[:])
+	}
+	return data
+}
+
+func WriteBinaryAccounts(path string, accounts []*Account) error {
+	return os.WriteFile(path, EncodeAccounts(accounts), 0600)
+}
+
+// BinaryAccountsCount reads the account count from the store header.
+func BinaryAccountsCount(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	header := make([]byte, headerSize)
+	_, err = file.ReadAt(header, 0)
+	if err != nil {
+		return 0, err
+	}
+	if !IsBinaryAccounts(header) {
+		return 0, fmt.Errorf("invalid binary accounts store")
+	}
+	return int(binary.LittleEndian.Uint64(header[8:])), nil
+}
+
+// TextToAccounts converts accounts of the legacy text format, one hex
+// private key per line.
+func TextToAccounts(keys []string) ([]*Account, error) {
+	accounts := make([]*Account, len(keys))
+	for i, hex := range keys {
+		key, err := crypto.HexToECDSA(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid account %d: %w", i, err)
+		}
+		accounts[i] = NewAccount(i, key)
+	}
+	return accounts, nil
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+type Change struct {
+	Method     string
+	Metric     string
+	Old        float64
+	New        float64
+	Delta      float64
+	Regression bool
+}
+
+// Diff compares latency percentiles, throughput and error rate of every
+// method of base. Delta is the relative change from base to target, a change
+// is a regression when it gets worse by more than threshold, anything worse
+// than a zero base is. Methods missing from target are regressions.
+func Diff(base, target *Report, threshold float64) []*Change {
+	var changes []*Change
+	compare := func(method string, o, n *Method) {
+		latencies := []struct {
+			metric       string
+			base, target float64
+		}{
+			{"p50", o.Latency.P50, n.Latency.P50},
+			{"p90", o.Latency.P90, n.Latency.P90},
+			{"p99", o.Latency.P99, n.Latency.P99},
+			{"p99.9", o.Latency.P999, n.Latency.P999},
+		}
+		for _, l := range latencies {
+			delta := relative(l.base, l.target)
+			changes = append(changes, &Change{
+				Method:     method,
+				Metric:     l.metric,
+				Old:        l.base,
+				New:        l.target,
+				Delta:      delta,
+				Regression: delta > threshold,
+			})
+		}
+		delta := relative(o.Throughput, n.Throughput)
+		changes = append(changes, &Change{
+			Method:     method,
+			Metric:     "throughput",
+			Old:        o.Throughput,
+			New:        n.Throughput,
+			Delta:      delta,
+			Regression: -delta > threshold,
+		})
+		oldRate, newRate := errorRate(o), errorRate(n)
+		delta = relative(oldRate, newRate)
+		changes = append(changes, &Change{
+			Method:     method,
+			Metric:     "error rate",
+			Old:        oldRate,
+			New:        newRate,
+			Delta:      delta,
+			Regression: delta > threshold,
+		})
+	}
+
+	for _, method := range base.MethodNames() {
+		o := base.Methods[method]
+		n, ok := target.Methods[method]
+		if !ok {
+			// vanished or failed before being recorded
+			changes = append(changes, &Change{
+				Method:     method,
+				Metric:     "requests",
+				Old:        float64(o.Requests),
+				Delta:      -1,
+				Regression: true,
+			})
+			continue
+		}
+		compare(method, o, n)
+	}
+	compare("total",
+		&Method{Requests: base.Requests, Errors: base.Errors, Throughput: base.Throughput, Latency: base.Latency},
+		&Method{Requests: target.Requests, Errors: target.Errors, Throughput: target.Throughput, Latency: target.Latency})
+	return changes
+}
+
+// relative returns the relative change from base to target, infinite when
+// a zero base changes.
+func relative(base, target float64) float64 {
+	if base == 0 {
+		if target == 0 {
+			return 0
+		}
+		return math.Inf(int(math.Copysign(1, target)))
+	}
+	return (target - base) / base
+}
+
+func errorRate(m *Method) float64 {
+	if m.Requests == 0 {
+		return 0
+	}
+	return float64(m.Errors) / float64(m.Requests)
+}
+
+// ParseThreshold parses a percentage such as "10%" or "10" into 0.1.
+func ParseThreshold(s string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid threshold %q: %w", s, err)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("invalid threshold %q, should not be negative", s)
+	}
+	return v / 100, nil
+}
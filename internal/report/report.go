@@ -0,0 +1,84 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"time"
+)
+
+// Latency percentiles are in milliseconds.
+type Latency struct {
+	Min  float64 `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	P99  float64 `json:"p99"`
+	P999 float64 `json:"p99_9"`
+	Max  float64 `json:"max"`
+}
+
+type Method struct {
+	Requests   int            `json:"requests"`
+	Success    int            `json:"success"`
+	Errors     int            `json:"errors"`
+	Throughput float64        `json:"throughput"`
+	Latency    Latency        `json:"latency"`
+	ErrorCodes map[string]int `json:"error_codes,omitempty"`
+}
+
+// Interval counts the requests completed in one second of the run.
+type Interval struct {
+	Second   int `json:"second"`
+	Requests int `json:"requests"`
+	Errors   int `json:"errors"`
+}
+
+type Report struct {
+	File       string             `json:"file"`
+	URL        string             `json:"url"`
+	Mode       string             `json:"mode"`
+	Start      time.Time          `json:"start"`
+	Elapsed    float64            `json:"elapsed"`
+	Requests   int                `json:"requests"`
+	Success    int                `json:"success"`
+	Errors     int                `json:"errors"`
+	Dropped    int                `json:"dropped"`
+	Throughput float64            `json:"throughput"`
+	Latency    Latency            `json:"latency"`
+	ErrorCodes map[string]int     `json:"error_codes,omitempty"`
+	Messages   map[string]string  `json:"error_messages,omitempty"`
+	Methods    map[string]*Method `json:"methods"`
+	Timeline   []Interval         `json:"timeline"`
+}
+
+func Load(path string) (*Report, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Report
+	err = json.Unmarshal(bytes, &r)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (r *Report) Write(path string) error {
+	bytes, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0644)
+}
+
+// MethodNames returns the reported methods in alphabetical order.
+func (r *Report) MethodNames() []string {
+	names := make([]string, 0, len(r.Methods))
+	for name := range r.Methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
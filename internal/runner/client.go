@@ -0,0 +1,96 @@
+package runner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/axiomesh/data-producer/internal/model"
+)
+
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json rpc error %d: %s", e.Code, e.Message)
+}
+
+type HTTPError struct {
+	Status int
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("http status %d", e.Status)
+}
+
+// IDMismatchError reports a response whose id doesn't match its request.
+type IDMismatchError struct {
+	Want model.ReqID
+	Got  model.ReqID
+}
+
+func (e *IDMismatchError) Error() string {
+	return fmt.Sprintf("response id %s doesn't match request id %s", e.Got, e.Want)
+}
+
+type RPCResponse struct {
+	ID      model.ReqID     `json:"id"`
+	JsonRpc string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+type Client struct {
+	url  string
+	http *http.Client
+}
+
+func NewClient(url string, concurrency int, timeout time.Duration) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = concurrency
+	transport.MaxIdleConnsPerHost = concurrency
+	return &Client{
+		url: url,
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		},
+	}
+}
+
+func (c *Client) Send(req *model.EthReq) (*RPCResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{Status: resp.StatusCode}
+	}
+	var rpcResp RPCResponse
+	err = json.Unmarshal(data, &rpcResp)
+	if err != nil {
+		return nil, err
+	}
+	if rpcResp.Error != nil {
+		return &rpcResp, rpcResp.Error
+	}
+	if rpcResp.ID != req.ID {
+		return &rpcResp, &IDMismatchError{Want: req.ID, Got: rpcResp.ID}
+	}
+	return &rpcResp, nil
+}
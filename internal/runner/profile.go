@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stage ramps the arrival rate linearly from the previous stage's target
+// to Target over Duration.
+type Stage struct {
+	Duration time.Duration
+	Target   float64
+}
+
+type Profile struct {
+	StartRate float64
+	Stages    []Stage
+}
+
+func NewConstantProfile(rate float64, duration time.Duration) *Profile {
+	return &Profile{
+		StartRate: rate,
+		Stages:    []Stage{{Duration: duration, Target: rate}},
+	}
+}
+
+func (p *Profile) Duration() time.Duration {
+	var total time.Duration
+	for _, stage := range p.Stages {
+		total += stage.Duration
+	}
+	return total
+}
+
+// Rate returns the target arrival rate (req/s) at elapsed, or -1 when the
+// profile is finished.
+func (p *Profile) Rate(elapsed time.Duration) float64 {
+	from := p.StartRate
+	for _, stage := range p.Stages {
+		if elapsed < stage.Duration {
+			progress := float64(elapsed) / float64(stage.Duration)
+			return from + (stage.Target-from)*progress
+		}
+		elapsed -= stage.Duration
+		from = stage.Target
+	}
+	return -1
+}
+
+// ParseStages parses stages in the form of "30s:1000,2m:5000,30s:0".
+func ParseStages(s string) ([]Stage, error) {
+	var stages []Stage
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		stage, err := parseStage(item)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no stage found in %q", s)
+	}
+	return stages, nil
+}
+
+// LoadStages reads one "duration:target" stage per line, lines starting
+// with '#' are ignored.
+func LoadStages(path string) ([]Stage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stages []Stage
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		stage, err := parseStage(line)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("no stage found in %s", path)
+	}
+	return stages, nil
+}
+
+func parseStage(s string) (Stage, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Stage{}, fmt.Errorf("invalid stage %q, should be duration:target", s)
+	}
+	duration, err := time.ParseDuration(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return Stage{}, fmt.Errorf("invalid stage %q: %w", s, err)
+	}
+	if duration <= 0 {
+		return Stage{}, fmt.Errorf("invalid stage %q, duration should be positive", s)
+	}
+	target, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return Stage{}, fmt.Errorf("invalid stage %q: %w", s, err)
+	}
+	if target < 0 {
+		return Stage{}, fmt.Errorf("invalid stage %q, target should not be negative", s)
+	}
+	return Stage{Duration: duration, Target: target}, nil
+}
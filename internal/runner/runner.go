@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/axiomesh/data-producer/internal/model"
+	"github.com/axiomesh/data-producer/internal/report"
+)
+
+// idleStep is the scheduling resolution used when the arrival rate is too
+// low to schedule requests one by one.
+const idleStep = time.Millisecond
+
+type Config struct {
+	URL         string
+	Concurrency int
+	Repeat      int
+	MaxInFlight int
+	Timeout     time.Duration
+}
+
+// Run replays reqs with a closed-loop worker pool, every worker sends the
+// next request once the previous one is answered.
+func Run(cfg *Config, reqs []*model.EthReq) *report.Report {
+	client := NewClient(cfg.URL, cfg.Concurrency, cfg.Timeout)
+	stats := NewStats()
+
+	queue := make(chan *model.EthReq, cfg.Concurrency)
+	wg := sync.WaitGroup{}
+	wg.Add(cfg.Concurrency)
+	for i := 0; i < cfg.Concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for req := range queue {
+				start := time.Now()
+				_, err := client.Send(req)
+				stats.Record(req.Method, time.Since(start), err)
+			}
+		}()
+	}
+
+	for i := 0; i < cfg.Repeat; i++ {
+		for _, req := range reqs {
+			queue <- req
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	r := stats.Report()
+	r.URL = cfg.URL
+	r.Mode = "closed-loop"
+	return r
+}
+
+// RunOpenLoop sends reqs (cycling when exhausted) at the arrival rate given
+// by profile, independent of response latency. Latency is measured from the
+// intended send time, so a slow node can't hide queueing delay. Requests
+// that would exceed MaxInFlight are dropped instead of delayed.
+func RunOpenLoop(cfg *Config, profile *Profile, reqs []*model.EthReq) *report.Report {
+	client := NewClient(cfg.URL, cfg.MaxInFlight, cfg.Timeout)
+	stats := NewStats()
+	inflight := make(chan struct{}, cfg.MaxInFlight)
+	wg := sync.WaitGroup{}
+
+	send := func(req *model.EthReq, intended time.Time) {
+		select {
+		case inflight <- struct{}{}:
+		default:
+			stats.Drop()
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.Send(req)
+			stats.Record(req.Method, time.Since(intended), err)
+			<-inflight
+		}()
+	}
+
+	start := time.Now()
+	var next time.Duration
+	var pending float64
+	for i := 0; ; {
+		rate := profile.Rate(next)
+		if rate < 0 {
+			break
+		}
+		if rate > 0 && float64(time.Second)/rate <= float64(idleStep) {
+			next += time.Duration(float64(time.Second) / rate)
+		} else {
+			pending += rate * idleStep.Seconds()
+			next += idleStep
+			if pending < 1 {
+				continue
+			}
+			pending--
+		}
+
+		if wait := next - time.Since(start); wait > 0 {
+			time.Sleep(wait)
+		}
+		send(reqs[i%len(reqs)], start.Add(next))
+		i++
+	}
+	wg.Wait()
+
+	r := stats.Report()
+	r.URL = cfg.URL
+	r.Mode = "open-loop"
+	return r
+}
@@ -0,0 +1,199 @@
+package runner
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/axiomesh/data-producer/internal/report"
+)
+
+const (
+	// histogram tracks latency in microseconds from 1µs to 1min
+	histogramMin     = 1
+	histogramMax     = int64(time.Minute / time.Microsecond)
+	histogramSigFigs = 3
+)
+
+type methodStats struct {
+	histogram  *hdrhistogram.Histogram
+	requests   int
+	errors     int
+	errorCodes map[string]int
+}
+
+func newMethodStats() *methodStats {
+	return &methodStats{
+		histogram:  hdrhistogram.New(histogramMin, histogramMax, histogramSigFigs),
+		errorCodes: make(map[string]int),
+	}
+}
+
+func (m *methodStats) record(latency time.Duration, code string) {
+	m.requests++
+	if code != "" {
+		m.errors++
+		m.errorCodes[code]++
+		return
+	}
+	us := latency.Microseconds()
+	if us < histogramMin {
+		us = histogramMin
+	}
+	if us > histogramMax {
+		us = histogramMax
+	}
+	_ = m.histogram.RecordValue(us)
+}
+
+type Stats struct {
+	lock     sync.Mutex
+	start    time.Time
+	total    *methodStats
+	methods  map[string]*methodStats
+	messages map[string]string
+	timeline []report.Interval
+	dropped  int
+}
+
+func NewStats() *Stats {
+	return &Stats{
+		start:    time.Now(),
+		total:    newMethodStats(),
+		methods:  make(map[string]*methodStats),
+		messages: make(map[string]string),
+	}
+}
+
+func (s *Stats) Record(method string, latency time.Duration, err error) {
+	code := errorCode(err)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	m, ok := s.methods[method]
+	if !ok {
+		m = newMethodStats()
+		s.methods[method] = m
+	}
+	m.record(latency, code)
+	s.total.record(latency, code)
+	if code != "" {
+		if _, ok := s.messages[code]; !ok {
+			s.messages[code] = err.Error()
+		}
+	}
+
+	second := int(time.Since(s.start) / time.Second)
+	for len(s.timeline) <= second {
+		s.timeline = append(s.timeline, report.Interval{Second: len(s.timeline)})
+	}
+	s.timeline[second].Requests++
+	if code != "" {
+		s.timeline[second].Errors++
+	}
+}
+
+func (s *Stats) Drop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.dropped++
+}
+
+func (s *Stats) Report() *report.Report {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	elapsed := time.Since(s.start)
+	r := &report.Report{
+		Start:      s.start,
+		Elapsed:    elapsed.Seconds(),
+		Requests:   s.total.requests,
+		Success:    s.total.requests - s.total.errors,
+		Errors:     s.total.errors,
+		Dropped:    s.dropped,
+		Throughput: throughput(s.total.requests, elapsed),
+		Latency:    latency(s.total.histogram),
+		ErrorCodes: copyCounts(s.total.errorCodes),
+		Messages:   make(map[string]string, len(s.messages)),
+		Methods:    make(map[string]*report.Method, len(s.methods)),
+		Timeline:   append([]report.Interval(nil), s.timeline...),
+	}
+	for code, msg := range s.messages {
+		r.Messages[code] = msg
+	}
+	for name, m := range s.methods {
+		r.Methods[name] = &report.Method{
+			Requests:   m.requests,
+			Success:    m.requests - m.errors,
+			Errors:     m.errors,
+			Throughput: throughput(m.requests, elapsed),
+			Latency:    latency(m.histogram),
+			ErrorCodes: copyCounts(m.errorCodes),
+		}
+	}
+	return r
+}
+
+// errorCode classifies err by its JSON-RPC error code, errors without a
+// JSON-RPC code are classified by kind.
+func errorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return strconv.Itoa(rpcErr.Code)
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return "http_" + strconv.Itoa(httpErr.Status)
+	}
+	var idErr *IDMismatchError
+	if errors.As(err, &idErr) {
+		return "id_mismatch"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return "transport"
+}
+
+func latency(h *hdrhistogram.Histogram) report.Latency {
+	if h.TotalCount() == 0 {
+		return report.Latency{}
+	}
+	ms := func(us int64) float64 {
+		return float64(us) / 1000
+	}
+	return report.Latency{
+		Min:  ms(h.Min()),
+		Mean: h.Mean() / 1000,
+		P50:  ms(h.ValueAtQuantile(50)),
+		P90:  ms(h.ValueAtQuantile(90)),
+		P99:  ms(h.ValueAtQuantile(99)),
+		P999: ms(h.ValueAtQuantile(99.9)),
+		Max:  ms(h.Max()),
+	}
+}
+
+func throughput(requests int, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(requests) / elapsed.Seconds()
+}
+
+func copyCounts(counts map[string]int) map[string]int {
+	if len(counts) == 0 {
+		return nil
+	}
+	res := make(map[string]int, len(counts))
+	for k, v := range counts {
+		res[k] = v
+	}
+	return res
+}
@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var units = []struct {
+	name string
+	wei  *big.Int
+}{
+	{"ether", big.NewInt(1e18)},
+	{"gwei", big.NewInt(1e9)},
+	{"wei", big.NewInt(1)},
+}
+
+// ParseAmount parses an amount in wei, gwei or ether such as "10ether",
+// "0.5ether", "500gwei" or raw wei "1000".
+func ParseAmount(s string) (*big.Int, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	number, unit := s, big.NewInt(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.name) {
+			number, unit = strings.TrimSpace(strings.TrimSuffix(s, u.name)), u.wei
+			break
+		}
+	}
+	value, ok := new(big.Rat).SetString(number)
+	if !ok || number == "" {
+		return nil, fmt.Errorf("invalid amount %q, should be like 10ether, 500gwei or 1000", s)
+	}
+	value.Mul(value, new(big.Rat).SetInt(unit))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("invalid amount %q, less than 1 wei", s)
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q, should not be negative", s)
+	}
+	return value.Num(), nil
+}
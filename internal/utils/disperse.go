@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DisperseABI and DisperseBIN are the multisend contract of testdata/disperse.sol
+const DisperseABI = "[{\"inputs\":[{\"internalType\":\"address[]\",\"name\":\"recipients\",\"type\":\"address[]\"},{\"internalType\":\"uint256[]\",\"name\":\"values\",\"type\":\"uint256[]\"}],\"name\":\"disperseEther\",\"outputs\":[],\"stateMutability\":\"payable\",\"type\":\"function\"}]"
+const DisperseBIN = "61008a80600c6000396000f360003560e01c63e63d38ed146300000018576300000085565b6004356004016024356004018135808235141563000000855760005b818110156300000069578060010160051b8085013590840135600080808084865af11563000000855750506001016300000034565b478015630000008357600080808084335af1156300000085575b005b600080fd"
+
+// DisperseGas is the gas of a disperse transaction funding recipients new
+// accounts.
+func DisperseGas(recipients int) uint64 {
+	return 50000 + 40000*uint64(recipients)
+}
+
+func DeployDisperse() (string, error) {
+	return deploy(DisperseBIN)
+}
+
+// SendDisperse sends a disperse transaction from the admin funding
+// recipients[i] with values[i], without waiting for the receipt.
+func SendDisperse(contractAddr string, recipients []common.Address, values []*big.Int, fees *Fees) (common.Hash, error) {
+	if admin == nil {
+		return common.Hash{}, ErrNoAdmin
+	}
+	loadABI, err := abi.JSON(strings.NewReader(DisperseABI))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	pack, err := loadABI.Pack("disperseEther", recipients, values)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	total := new(big.Int)
+	for _, value := range values {
+		total.Add(total, value)
+	}
+	toAddress := common.HexToAddress(contractAddr)
+	gas, err := GasLimit(ethereum.CallMsg{From: admin.Address(), To: &toAddress, Value: total, Data: pack}, DisperseGas(len(recipients)))
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	signTx, err := sendTx(admin, func(nonce uint64) *types.Transaction {
+		return newTx(nonce, &toAddress, total, gas, pack, fees, nil)
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return signTx.Hash(), nil
+}
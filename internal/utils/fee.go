@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	TxTypeLegacy     = "legacy"
+	TxTypeAccessList = "accesslist"
+	TxTypeDynamic    = "dynamic"
+)
+
+// feeHistoryPercentile is the reward percentile of recent blocks a dynamic
+// fee tip falls back to when the node has no eth_maxPriorityFeePerGas
+const feeHistoryPercentile = 50
+
+var txType = TxTypeLegacy
+
+func checkTxType(t string) error {
+	switch t {
+	case TxTypeLegacy, TxTypeAccessList, TxTypeDynamic:
+		return nil
+	}
+	return fmt.Errorf("unsupported tx type %s, should be legacy, accesslist or dynamic", t)
+}
+
+// TxType returns the type of the transactions built, legacy, accesslist or
+// dynamic.
+func TxType() string {
+	return txType
+}
+
+// Fees are the gas price of legacy and access list transactions, or the tip
+// and fee caps of dynamic fee transactions.
+type Fees struct {
+	GasPrice  *big.Int
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// SuggestFees suggests the fees of the configured tx type, dynamic fee caps
+// leave room for the base fee to double.
+func SuggestFees() (*Fees, error) {
+	if txType != TxTypeDynamic {
+		gasPrice, err := client.SuggestGasPrice(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		return &Fees{GasPrice: gasPrice}, nil
+	}
+	history, err := client.FeeHistory(context.Background(), 1, nil, []float64{feeHistoryPercentile})
+	if err != nil {
+		return nil, fmt.Errorf("query fee history failed: %w", err)
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, fmt.Errorf("node reports no base fee, try --tx-type legacy")
+	}
+	// the last base fee is the next block's
+	baseFee := history.BaseFee[len(history.BaseFee)-1]
+	tip, err := client.SuggestGasTipCap(context.Background())
+	if err != nil {
+		if len(history.Reward) == 0 || len(history.Reward[0]) == 0 {
+			return nil, fmt.Errorf("suggest priority fee failed: %w", err)
+		}
+		tip = history.Reward[0][0]
+	}
+	feeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+	return &Fees{GasTipCap: tip, GasFeeCap: feeCap}, nil
+}
+
+// Cost returns the most a transaction of gas may pay for it.
+func (f *Fees) Cost(gas uint64) *big.Int {
+	price := f.GasPrice
+	if price == nil {
+		price = f.GasFeeCap
+	}
+	return new(big.Int).Mul(price, new(big.Int).SetUint64(gas))
+}
+
+// newTx builds a transaction of the configured tx type, accessList only
+// goes into access list and dynamic fee transactions.
+func newTx(nonce uint64, to *common.Address, value *big.Int, gas uint64, data []byte, fees *Fees, accessList types.AccessList) *types.Transaction {
+	switch txType {
+	case TxTypeAccessList:
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         to,
+			Value:      value,
+			Gas:        gas,
+			GasPrice:   fees.GasPrice,
+			Data:       data,
+			AccessList: accessList,
+		})
+	case TxTypeDynamic:
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    chainID,
+			Nonce:      nonce,
+			To:         to,
+			Value:      value,
+			Gas:        gas,
+			GasTipCap:  fees.GasTipCap,
+			GasFeeCap:  fees.GasFeeCap,
+			Data:       data,
+			AccessList: accessList,
+		})
+	default:
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			To:       to,
+			Value:    value,
+			Gas:      gas,
+			GasPrice: fees.GasPrice,
+			Data:     data,
+		})
+	}
+}
+
+// storeAccessList warms the slot the store method of the contract writes.
+func storeAccessList(contract common.Address) types.AccessList {
+	return types.AccessList{{Address: contract, StorageKeys: []common.Hash{{}}}}
+}
@@ -0,0 +1,268 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	GasPriceSuggested  = "suggested"
+	GasPriceFixed      = "fixed"
+	GasPriceEscalating = "escalating"
+	GasPriceRandom     = "random"
+
+	GasLimitDefault  = "default"
+	GasLimitEstimate = "estimate"
+)
+
+// DefaultGasPad is the percentage estimated gas limits are raised by
+const DefaultGasPad = 20
+
+// GasStrategy prices transactions and sets their gas limits. The price is
+// the gas price of legacy and access list transactions and the priority
+// fee of dynamic fee transactions.
+type GasStrategy struct {
+	Price string
+	// Multiplier scales the suggested price of suggested and escalating
+	Multiplier float64
+	// Fixed is the price of fixed
+	Fixed *big.Int
+	// Step is the percentage escalating raises the price by per transaction
+	Step float64
+	// Min and Max bound random, Max caps escalating
+	Min *big.Int
+	Max *big.Int
+
+	// Limit is a fixed gas limit, 0 keeps the limit of every kind of
+	// transaction unless Estimate
+	Limit    uint64
+	Estimate bool
+	// Pad is the percentage estimated gas limits are raised by
+	Pad float64
+}
+
+var gasStrategy = DefaultGasStrategy()
+
+func DefaultGasStrategy() *GasStrategy {
+	return &GasStrategy{Price: GasPriceSuggested, Multiplier: 1}
+}
+
+// ParseGasStrategy parses the gas price strategy, one of
+//
+//	suggested[:<multiplier>]
+//	fixed:<price>
+//	escalating:<step%>[:<max price>]
+//	random:<min price>-<max price>
+//
+// and the gas limit strategy, default, a fixed limit or estimate[:<pad%>].
+// Prices take units like 2gwei, empty strategies are the defaults.
+func ParseGasStrategy(price, limit string) (*GasStrategy, error) {
+	s := DefaultGasStrategy()
+	name, arg, hasArg := strings.Cut(strings.TrimSpace(price), ":")
+	var err error
+	switch name {
+	case "", GasPriceSuggested:
+		if hasArg {
+			s.Multiplier, err = parseGasFactor(arg, "multiplier")
+		}
+	case GasPriceFixed:
+		s.Price = GasPriceFixed
+		s.Fixed, err = ParseAmount(arg)
+	case GasPriceEscalating:
+		s.Price = GasPriceEscalating
+		step, max, hasMax := strings.Cut(arg, ":")
+		s.Step, err = parseGasFactor(strings.TrimSuffix(step, "%"), "step")
+		if err == nil && hasMax {
+			s.Max, err = ParseAmount(max)
+		}
+	case GasPriceRandom:
+		s.Price = GasPriceRandom
+		min, max, ok := strings.Cut(arg, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid gas price %q, should be random:<min>-<max>", price)
+		}
+		s.Min, err = ParseAmount(min)
+		if err == nil {
+			s.Max, err = ParseAmount(max)
+		}
+		if err == nil && s.Min.Cmp(s.Max) > 0 {
+			err = fmt.Errorf("min is larger than max")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported gas price %q, should be suggested, fixed, escalating or random", price)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas price %q: %w", price, err)
+	}
+
+	name, arg, hasArg = strings.Cut(strings.TrimSpace(limit), ":")
+	switch name {
+	case "", GasLimitDefault:
+	case GasLimitEstimate:
+		s.Estimate = true
+		s.Pad = DefaultGasPad
+		if hasArg {
+			s.Pad, err = strconv.ParseFloat(strings.TrimSuffix(arg, "%"), 64)
+			if err == nil && s.Pad < 0 {
+				err = fmt.Errorf("pad should not be negative")
+			}
+		}
+	default:
+		s.Limit, err = strconv.ParseUint(name, 10, 64)
+		if err == nil && s.Limit == 0 {
+			err = fmt.Errorf("limit should be positive")
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid gas limit %q: %w", limit, err)
+	}
+	return s, nil
+}
+
+func parseGasFactor(s, name string) (float64, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f <= 0 {
+		return 0, fmt.Errorf("%s should be positive", name)
+	}
+	return f, nil
+}
+
+// GasPricer hands out the fees of consecutive transactions following the
+// gas strategy, starting from the fees suggested once by the node.
+type GasPricer struct {
+	mu       sync.Mutex
+	strategy *GasStrategy
+	// headroom is what dynamic fee caps add to the priority fee
+	headroom *big.Int
+	dynamic  bool
+	price    *big.Int
+	max      *big.Int
+	rnd      *rand.Rand
+}
+
+func NewGasPricer() (*GasPricer, error) {
+	s := gasStrategy
+	p := &GasPricer{
+		strategy: s,
+		dynamic:  txType == TxTypeDynamic,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	var suggested *big.Int
+	if s.Price == GasPriceSuggested || s.Price == GasPriceEscalating || p.dynamic {
+		fees, err := SuggestFees()
+		if err != nil {
+			return nil, err
+		}
+		suggested = fees.GasPrice
+		if p.dynamic {
+			suggested = fees.GasTipCap
+			p.headroom = new(big.Int).Sub(fees.GasFeeCap, fees.GasTipCap)
+		}
+	}
+	switch s.Price {
+	case GasPriceFixed:
+		p.price = s.Fixed
+		p.max = s.Fixed
+	case GasPriceRandom:
+		p.price = s.Min
+		p.max = s.Max
+	default:
+		p.price = scale(suggested, s.Multiplier)
+		p.max = p.price
+		if s.Price == GasPriceEscalating {
+			// escalating stops at twice its start unless capped
+			p.max = s.Max
+			if p.max == nil {
+				p.max = new(big.Int).Mul(p.price, big.NewInt(2))
+			}
+			if p.max.Cmp(p.price) < 0 {
+				p.price = p.max
+			}
+		}
+	}
+	return p, nil
+}
+
+func scale(x *big.Int, f float64) *big.Int {
+	if f == 1 {
+		return new(big.Int).Set(x)
+	}
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(x), big.NewFloat(f)).Int(nil)
+	return scaled
+}
+
+// Fees returns the fees of the next transaction.
+func (p *GasPricer) Fees() *Fees {
+	var price *big.Int
+	switch p.strategy.Price {
+	case GasPriceRandom:
+		span := new(big.Int).Sub(p.max, p.price)
+		span.Add(span, big.NewInt(1))
+		p.mu.Lock()
+		price = new(big.Int).Rand(p.rnd, span)
+		p.mu.Unlock()
+		price.Add(price, p.price)
+	case GasPriceEscalating:
+		p.mu.Lock()
+		price = p.price
+		next := scale(p.price, 1+p.strategy.Step/100)
+		if next.Cmp(p.price) == 0 {
+			next.Add(next, big.NewInt(1))
+		}
+		if next.Cmp(p.max) > 0 {
+			next = p.max
+		}
+		p.price = next
+		p.mu.Unlock()
+	default:
+		price = p.price
+	}
+	return p.fees(price)
+}
+
+// MaxFees returns the highest fees Fees may return.
+func (p *GasPricer) MaxFees() *Fees {
+	return p.fees(p.max)
+}
+
+func (p *GasPricer) fees(price *big.Int) *Fees {
+	if p.dynamic {
+		return &Fees{GasTipCap: price, GasFeeCap: new(big.Int).Add(p.headroom, price)}
+	}
+	return &Fees{GasPrice: price}
+}
+
+// TransferGasLimit returns the gas limit of a transfer following the gas
+// strategy.
+func TransferGasLimit(from, to common.Address, value *big.Int) (uint64, error) {
+	return GasLimit(ethereum.CallMsg{From: from, To: &to, Value: value}, TransferGas)
+}
+
+// GasLimit returns the gas limit of msg following the gas strategy, gas is
+// the limit of its kind of transaction.
+func GasLimit(msg ethereum.CallMsg, gas uint64) (uint64, error) {
+	s := gasStrategy
+	if s.Limit != 0 {
+		return s.Limit, nil
+	}
+	if !s.Estimate {
+		return gas, nil
+	}
+	estimated, err := client.EstimateGas(context.Background(), msg)
+	if err != nil {
+		return 0, fmt.Errorf("estimate gas failed: %w", err)
+	}
+	return estimated + uint64(float64(estimated)*s.Pad/100), nil
+}
@@ -0,0 +1,233 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// sendAttempts bounds the sends of a transaction refused for its nonce
+const sendAttempts = 5
+
+// sendRetryDelay is the wait for lower nonces in flight before resending a
+// transaction refused for a too high nonce
+const sendRetryDelay = 200 * time.Millisecond
+
+// NonceManager hands out the nonces of every sender, starting from the
+// sender's pending nonce on the node and tracking them locally afterwards.
+// Nonces of transactions the node refused are released and handed out
+// again before new ones, so a failed send leaves no gap.
+type NonceManager struct {
+	mu      sync.Mutex
+	senders map[common.Address]*senderNonce
+	pending func(address common.Address) (uint64, error)
+}
+
+type senderNonce struct {
+	next uint64
+	// released nonces below next, ascending
+	gaps []uint64
+}
+
+func NewNonceManager(pending func(address common.Address) (uint64, error)) *NonceManager {
+	return &NonceManager{
+		senders: make(map[common.Address]*senderNonce),
+		pending: pending,
+	}
+}
+
+// sender returns the nonces of address, querying the node for a new sender
+// without holding the lock so that new senders don't wait for each other.
+// m.mu is held on entry and on return.
+func (m *NonceManager) sender(address common.Address) (*senderNonce, error) {
+	s, ok := m.senders[address]
+	if ok {
+		return s, nil
+	}
+	m.mu.Unlock()
+	next, err := m.pending(address)
+	m.mu.Lock()
+	if err != nil {
+		return nil, err
+	}
+	// another caller may have added it meanwhile
+	s, ok = m.senders[address]
+	if ok {
+		return s, nil
+	}
+	s = &senderNonce{next: next}
+	m.senders[address] = s
+	return s, nil
+}
+
+// Next takes the next nonce of address.
+func (m *NonceManager) Next(address common.Address) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.sender(address)
+	if err != nil {
+		return 0, err
+	}
+	if len(s.gaps) > 0 {
+		nonce := s.gaps[0]
+		s.gaps = s.gaps[1:]
+		return nonce, nil
+	}
+	s.next++
+	return s.next - 1, nil
+}
+
+// Peek returns the nonce Next would take without taking it.
+func (m *NonceManager) Peek(address common.Address) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.sender(address)
+	if err != nil {
+		return 0, err
+	}
+	if len(s.gaps) > 0 {
+		return s.gaps[0], nil
+	}
+	return s.next, nil
+}
+
+// Release gives back a nonce taken by Next whose transaction never reached
+// the node.
+func (m *NonceManager) Release(address common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.senders[address]
+	if !ok || nonce >= s.next {
+		return
+	}
+	if nonce == s.next-1 {
+		s.next--
+		return
+	}
+	i := sort.Search(len(s.gaps), func(i int) bool { return s.gaps[i] >= nonce })
+	if i < len(s.gaps) && s.gaps[i] == nonce {
+		return
+	}
+	s.gaps = append(s.gaps, 0)
+	copy(s.gaps[i+1:], s.gaps[i:])
+	s.gaps[i] = nonce
+}
+
+// Resync catches up with the pending nonce of address on the node, nonces
+// the node already has are no longer handed out.
+func (m *NonceManager) Resync(address common.Address) error {
+	pending, err := m.pending(address)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.senders[address]
+	if !ok {
+		m.senders[address] = &senderNonce{next: pending}
+		return nil
+	}
+	if s.next < pending {
+		s.next = pending
+	}
+	i := sort.Search(len(s.gaps), func(i int) bool { return s.gaps[i] >= pending })
+	s.gaps = s.gaps[i:]
+	return nil
+}
+
+// Abandon gives back a nonce the node keeps refusing as too high and
+// catches up with the node, nonces other transactions of address hold are
+// kept.
+func (m *NonceManager) Abandon(address common.Address, nonce uint64) error {
+	m.Release(address, nonce)
+	return m.Resync(address)
+}
+
+func isNonceTooLow(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "replacement transaction underpriced")
+}
+
+func isNonceTooHigh(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too high") || strings.Contains(msg, "nonce gap")
+}
+
+// isRejected reports whether the node answered the send with an error, as
+// opposed to the send failing on the way where the node may still have
+// received the transaction.
+func isRejected(err error) bool {
+	var rpcErr rpc.Error
+	return errors.As(err, &rpcErr)
+}
+
+// sendTx signs and sends the transaction built for the signer's next nonce.
+// A nonce the node already has is resynced and replaced, a nonce above the
+// node's is resent while lower nonces may still be in flight and given back
+// when they never arrive, a send failing on the way is resent as is, and the
+// nonce of a transaction the node refused otherwise is released for the
+// next one.
+func sendTx(signer Signer, build func(nonce uint64) *types.Transaction) (*types.Transaction, error) {
+	address := signer.Address()
+	var signTx *types.Transaction
+	// sent is set once signTx may have reached the node
+	var sent bool
+	var err error
+	for attempt := 0; attempt < sendAttempts; attempt++ {
+		if signTx == nil {
+			var nonce uint64
+			nonce, err = nonces.Next(address)
+			if err != nil {
+				return nil, err
+			}
+			signTx, err = signer.SignTx(build(nonce), chainID)
+			if err != nil {
+				nonces.Release(address, nonce)
+				return nil, err
+			}
+			sent = false
+		}
+		err = client.SendTransaction(context.Background(), signTx)
+		switch {
+		case err == nil || strings.Contains(strings.ToLower(err.Error()), "already known"):
+			// already known is the very same transaction sent before
+			return signTx, nil
+		case isNonceTooLow(err):
+			if sent && isKnownTx(signTx.Hash()) {
+				// an earlier send got through after all
+				return signTx, nil
+			}
+			signTx = nil
+			if resyncErr := nonces.Resync(address); resyncErr != nil {
+				return nil, errors.Join(err, resyncErr)
+			}
+		case isNonceTooHigh(err):
+			time.Sleep(sendRetryDelay)
+		case !isRejected(err):
+			// the nonce stays taken, the node may have the transaction
+			sent = true
+			time.Sleep(sendRetryDelay)
+		default:
+			nonces.Release(address, signTx.Nonce())
+			return nil, err
+		}
+	}
+	if isNonceTooHigh(err) {
+		if abandonErr := nonces.Abandon(address, signTx.Nonce()); abandonErr != nil {
+			return nil, errors.Join(err, abandonErr)
+		}
+	}
+	return nil, err
+}
+
+func isKnownTx(hash common.Hash) bool {
+	_, _, err := client.TransactionByHash(context.Background(), hash)
+	return err == nil
+}
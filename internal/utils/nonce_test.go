@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestNonceManager(t *testing.T) {
+	address := common.HexToAddress("0x01")
+	tests := []struct {
+		name string
+		// nonces taken before releasing
+		take    int
+		release []uint64
+		// pending nonce on the node to resync to, 0 skips the resync
+		resync uint64
+		// nonces taken afterwards
+		want []uint64
+	}{
+		{
+			name:    "release in the middle",
+			take:    4,
+			release: []uint64{11},
+			want:    []uint64{11, 14, 15},
+		},
+		{
+			name:    "release the last nonce",
+			take:    4,
+			release: []uint64{13},
+			want:    []uint64{13, 14},
+		},
+		{
+			name:    "release out of order",
+			take:    5,
+			release: []uint64{12, 10, 12},
+			want:    []uint64{10, 12, 15},
+		},
+		{
+			name:    "release unknown nonce",
+			take:    2,
+			release: []uint64{12, 20},
+			want:    []uint64{12, 13},
+		},
+		{
+			name:    "resync past gaps",
+			take:    5,
+			release: []uint64{11, 13},
+			resync:  13,
+			want:    []uint64{13, 15, 16},
+		},
+		{
+			name:    "resync past next",
+			take:    3,
+			release: []uint64{11},
+			resync:  20,
+			want:    []uint64{20, 21},
+		},
+		{
+			name:   "resync behind next",
+			take:   3,
+			resync: 11,
+			want:   []uint64{13, 14},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pending := uint64(10)
+			m := NewNonceManager(func(common.Address) (uint64, error) {
+				return pending, nil
+			})
+			for i := 0; i < tt.take; i++ {
+				nonce, err := m.Next(address)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if nonce != 10+uint64(i) {
+					t.Fatalf("take %d: got nonce %d", i, nonce)
+				}
+			}
+			for _, nonce := range tt.release {
+				m.Release(address, nonce)
+			}
+			if tt.resync != 0 {
+				pending = tt.resync
+				if err := m.Resync(address); err != nil {
+					t.Fatal(err)
+				}
+			}
+			peek, err := m.Peek(address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if peek != tt.want[0] {
+				t.Errorf("peek got %d, want %d", peek, tt.want[0])
+			}
+			var got []uint64
+			for range tt.want {
+				nonce, err := m.Next(address)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, nonce)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got nonces %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNonceManagerConcurrentAbandon(t *testing.T) {
+	address := common.HexToAddress("0x01")
+	// the node is behind every nonce handed out, sends hit nonce too high
+	m := NewNonceManager(func(common.Address) (uint64, error) {
+		return 10, nil
+	})
+	const senders = 32
+	held := make([]uint64, senders)
+	var wg sync.WaitGroup
+	wg.Add(senders)
+	for i := 0; i < senders; i++ {
+		go func(i int) {
+			defer wg.Done()
+			nonce, err := m.Next(address)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if i%2 == 0 {
+				if err := m.Abandon(address, nonce); err != nil {
+					t.Error(err)
+					return
+				}
+				nonce, err = m.Next(address)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			held[i] = nonce
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[uint64]int)
+	for i, nonce := range held {
+		if j, ok := seen[nonce]; ok {
+			t.Fatalf("senders %d and %d both hold nonce %d", j, i, nonce)
+		}
+		seen[nonce] = i
+	}
+}
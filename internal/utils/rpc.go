@@ -3,11 +3,11 @@ package utils
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
 	"strings"
-	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum"
@@ -20,88 +20,151 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+// AdminPrivateKey is the admin of the default axiom devnet
 const AdminPrivateKey = "b6477143e17f889263044f6cf463dc37177ac4526c4c39a7a344198457024a2f" // axiom admin // axiom json rpc
 const ContractABI = "[{\"inputs\":[],\"name\":\"retrieve\",\"outputs\":[{\"internalType\":\"uint64\",\"name\":\"\",\"type\":\"uint64\"}],\"stateMutability\":\"view\",\"type\":\"function\"},{\"inputs\":[{\"internalType\":\"uint64\",\"name\":\"num\",\"type\":\"uint64\"}],\"name\":\"store\",\"outputs\":[],\"stateMutability\":\"nonpayable\",\"type\":\"function\"}]"
 const ContractBIN = "608060405234801561001057600080fd5b50610186806100206000396000f3fe608060405234801561001057600080fd5b50600436106100365760003560e01c80631d9a3bdd1461003b5780632e64cec114610057575b600080fd5b610055600480360381019061005091906100d2565b610075565b005b61005f6100a0565b60405161006c919061010a565b60405180910390f35b806000806101000a81548167ffffffffffffffff021916908367ffffffffffffffff16021790555050565b60008060009054906101000a900467ffffffffffffffff16905090565b6000813590506100cc81610139565b92915050565b6000602082840312156100e457600080fd5b60006100f2848285016100bd565b91505092915050565b61010481610125565b82525050565b600060208201905061011f60008301846100fb565b92915050565b600067ffffffffffffffff82169050919050565b61014281610125565b811461014d57600080fd5b5056fea26469706673582212204691849347a2f1bef4241dc7ceacb8f17c8556dad30e2a1f78e8450c908986a764736f6c63430008040033"
 
+// TransferGas is the gas of a plain value transfer
+const TransferGas = uint64(21000)
+
 var client *ethclient.Client
-var nonce uint64
+var nonces *NonceManager
+var chainID *big.Int
+var admin Signer
+
+var ErrNoAdmin = errors.New("admin signer not configured")
+
+// ErrReceiptTimeout is a transaction not mined within the receipt timeout,
+// it may still be mined later
+var ErrReceiptTimeout = errors.New("transaction not mined yet")
+
+// DefaultReceiptTimeout bounds waiting for a transaction to be mined
+const DefaultReceiptTimeout = 2 * time.Minute
 
-func InitClient(url string) error {
+const receiptPollInterval = 500 * time.Millisecond
+
+var receiptTimeout = DefaultReceiptTimeout
+
+type ClientConfig struct {
+	URL string
+	// ChainID is queried from the node when zero
+	ChainID uint64
+	// Admin funds accounts and deploys contracts, transactions of the admin
+	// fail with ErrNoAdmin when nil
+	Admin Signer
+	// TxType is the type of the transactions built, legacy when empty
+	TxType string
+	// Gas prices transactions and sets their gas limits, the suggested
+	// price and default limits when nil
+	Gas *GasStrategy
+	// ReceiptTimeout bounds waiting for receipts, DefaultReceiptTimeout
+	// when zero
+	ReceiptTimeout time.Duration
+}
+
+func InitClient(cfg *ClientConfig) error {
+	txType = TxTypeLegacy
+	if cfg.TxType != "" {
+		err := checkTxType(cfg.TxType)
+		if err != nil {
+			return err
+		}
+		txType = cfg.TxType
+	}
+	gasStrategy = DefaultGasStrategy()
+	if cfg.Gas != nil {
+		gasStrategy = cfg.Gas
+	}
+	receiptTimeout = DefaultReceiptTimeout
+	if cfg.ReceiptTimeout > 0 {
+		receiptTimeout = cfg.ReceiptTimeout
+	}
 	// init client
-	rpc, err := ethclient.Dial(url)
+	rpc, err := ethclient.Dial(cfg.URL)
 	if err != nil {
 		return err
 	}
 	client = rpc
-	// init nonce
-	adminKey, err := crypto.HexToECDSA(AdminPrivateKey)
-	if err != nil {
-		return err
-	}
-	from := crypto.PubkeyToAddress(adminKey.PublicKey)
-	nonce, err = client.PendingNonceAt(context.Background(), from)
-	if err != nil {
-		return err
+	// init chain id
+	if cfg.ChainID != 0 {
+		chainID = new(big.Int).SetUint64(cfg.ChainID)
+	} else {
+		chainID, err = client.NetworkID(context.Background())
+		if err != nil {
+			return err
+		}
 	}
-	nonce--
+	// init nonce
+	nonces = NewNonceManager(func(address common.Address) (uint64, error) {
+		return client.PendingNonceAt(context.Background(), address)
+	})
+	admin = cfg.Admin
 	return nil
 }
 
-func TransferFromAdmin(key *ecdsa.PrivateKey) error {
-	adminKey, err := crypto.HexToECDSA(AdminPrivateKey)
+// Nonces returns the nonce manager shared by all senders.
+func Nonces() *NonceManager {
+	return nonces
+}
+
+// Admin returns the admin signer, nil when not configured.
+func Admin() Signer {
+	return admin
+}
+
+// SendTransfer signs and sends a transfer of value wei with the fees
+// without waiting for the receipt.
+func SendTransfer(signer Signer, to common.Address, value *big.Int, fees *Fees) (common.Hash, error) {
+	gas, err := TransferGasLimit(signer.Address(), to, value)
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
-	to := crypto.PubkeyToAddress(key.PublicKey)
-	// 1 BXH
-	value := big.NewInt(1000000000000000000)
-	gasLimit := uint64(21000)
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return err
-	}
-	chainID, err := client.NetworkID(context.Background())
+	signTx, err := sendTx(signer, func(nonce uint64) *types.Transaction {
+		return newTx(nonce, &to, value, gas, []byte{}, fees, nil)
+	})
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
+	return signTx.Hash(), nil
+}
 
-	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    atomic.AddUint64(&nonce, 1),
-		To:       &to,
-		Value:    value,
-		Gas:      gasLimit,
-		GasPrice: gasPrice,
-		Data:     []byte{},
-	})
-	signTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), adminKey)
+// WaitReceipt waits for the receipt of a transaction and checks its status,
+// a transaction not mined within the receipt timeout is ErrReceiptTimeout.
+func WaitReceipt(hash common.Hash) error {
+	receipt, err := waitReceipt(hash)
 	if err != nil {
 		return err
 	}
-	err = client.SendTransaction(context.Background(), signTx)
-	if err != nil {
-		return err
+	if receipt.Status != uint64(1) {
+		return fmt.Errorf("transaction %s failed", hash)
 	}
+	return nil
+}
 
-	hash := signTx.Hash()
-	err = retry.Retry(func(attempt uint) error {
-		receipt, err := client.TransactionReceipt(context.Background(), hash)
-		if err != nil {
-			return err
-		}
-		if receipt.Status != uint64(1) {
-			return fmt.Errorf("transfer from admin error")
-		}
-		return nil
-	}, strategy.Limit(3), strategy.Delay(time.Millisecond*500))
-	if err != nil {
+// waitReceipt polls the receipt of a transaction until the receipt timeout.
+func waitReceipt(hash common.Hash) (*types.Receipt, error) {
+	deadline := time.Now().Add(receiptTimeout)
+	var receipt *types.Receipt
+	err := retry.Retry(func(attempt uint) error {
+		var err error
+		receipt, err = client.TransactionReceipt(context.Background(), hash)
 		return err
+	}, func(attempt uint) bool {
+		return attempt == 0 || time.Now().Before(deadline)
+	}, strategy.Wait(receiptPollInterval))
+	if err != nil {
+		// not found or the node unreachable, either way it may still be mined
+		return nil, fmt.Errorf("%w: %s: %s", ErrReceiptTimeout, hash, err)
 	}
-	return nil
+	return receipt, nil
+}
+
+func Balance(address common.Address) (*big.Int, error) {
+	return client.BalanceAt(context.Background(), address, nil)
 }
 
 func GetBlockHighMax() (int, error) {
@@ -142,56 +205,45 @@ func GetTxRandomHash() (string, error) {
 }
 
 func DeployContract() (string, error) {
-	bytecode := common.Hex2Bytes(ContractBIN)
-	adminKey, err := crypto.HexToECDSA(AdminPrivateKey)
-	if err != nil {
-		return "", err
+	return deploy(ContractBIN)
+}
+
+// deploy deploys a contract from the admin and returns its address.
+func deploy(bin string) (string, error) {
+	if admin == nil {
+		return "", ErrNoAdmin
 	}
-	gasLimit := uint64(210000)
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	bytecode := common.Hex2Bytes(bin)
+	gasLimit, err := GasLimit(ethereum.CallMsg{From: admin.Address(), Data: bytecode}, 210000)
 	if err != nil {
 		return "", err
 	}
-	chainID, err := client.NetworkID(context.Background())
+	pricer, err := NewGasPricer()
 	if err != nil {
 		return "", err
 	}
 
-	tx := types.NewTx(&types.LegacyTx{
-		Nonce:    atomic.AddUint64(&nonce, 1),
-		Gas:      gasLimit,
-		GasPrice: gasPrice,
-		Data:     bytecode,
+	signTx, err := sendTx(admin, func(nonce uint64) *types.Transaction {
+		return newTx(nonce, nil, nil, gasLimit, bytecode, pricer.Fees(), nil)
 	})
-	signTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), adminKey)
-	if err != nil {
-		return "", err
-	}
-	err = client.SendTransaction(context.Background(), signTx)
 	if err != nil {
 		return "", err
 	}
 
-	hash := signTx.Hash()
-	var address string
-	err = retry.Retry(func(attempt uint) error {
-		receipt, err := client.TransactionReceipt(context.Background(), hash)
-		if err != nil {
-			return err
-		}
-		if receipt.Status != uint64(1) {
-			return fmt.Errorf("deploy contract error")
-		}
-		address = receipt.ContractAddress.String()
-		return nil
-	}, strategy.Limit(3), strategy.Delay(time.Millisecond*500))
+	receipt, err := waitReceipt(signTx.Hash())
 	if err != nil {
 		return "", err
 	}
-	return address, nil
+	if receipt.Status != uint64(1) {
+		return "", fmt.Errorf("deploy contract error")
+	}
+	return receipt.ContractAddress.String(), nil
 }
 
 func Store(address string, value uint64) error {
+	if admin == nil {
+		return ErrNoAdmin
+	}
 	loadABI, err := abi.JSON(strings.NewReader(ContractABI))
 	if err != nil {
 		return err
@@ -200,86 +252,76 @@ func Store(address string, value uint64) error {
 	if err != nil {
 		return err
 	}
-	adminKey, err := crypto.HexToECDSA(AdminPrivateKey)
-	if err != nil {
-		return err
-	}
-	gasLimit := uint64(210000)
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	toAddress := common.HexToAddress(address)
+	gasLimit, err := GasLimit(ethereum.CallMsg{From: admin.Address(), To: &toAddress, Data: pack}, 210000)
 	if err != nil {
 		return err
 	}
-	chainID, err := client.NetworkID(context.Background())
+	pricer, err := NewGasPricer()
 	if err != nil {
 		return err
 	}
-	toAddress := common.HexToAddress(address)
 
-	tx := types.NewTx(&types.LegacyTx{
-		To:       &toAddress,
-		Nonce:    atomic.AddUint64(&nonce, 1),
-		Gas:      gasLimit,
-		GasPrice: gasPrice,
-		Data:     pack,
+	signTx, err := sendTx(admin, func(nonce uint64) *types.Transaction {
+		return newTx(nonce, &toAddress, nil, gasLimit, pack, pricer.Fees(), storeAccessList(toAddress))
 	})
-	signTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), adminKey)
-	if err != nil {
-		return err
-	}
-	err = client.SendTransaction(context.Background(), signTx)
 	if err != nil {
 		return err
 	}
 
-	hash := signTx.Hash()
-	err = retry.Retry(func(attempt uint) error {
-		receipt, err := client.TransactionReceipt(context.Background(), hash)
-		if err != nil {
-			return err
-		}
-		if receipt.Status != uint64(1) {
-			return fmt.Errorf("invoke contract store error")
-		}
-		return nil
-	}, strategy.Limit(3), strategy.Delay(time.Millisecond*500))
+	receipt, err := waitReceipt(signTx.Hash())
 	if err != nil {
 		return err
 	}
+	if receipt.Status != uint64(1) {
+		return fmt.Errorf("invoke contract store error")
+	}
 	return nil
 }
 
-func GenEstimateGasTx(contractAddr, privateKey string, value uint64) (*types.Transaction, error) {
+// StoreGasLimit returns the gas limit of store calls of from to the
+// contract following the gas strategy, estimated once for all of them.
+func StoreGasLimit(contractAddr string, from common.Address) (uint64, error) {
 	loadABI, err := abi.JSON(strings.NewReader(ContractABI))
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	pack, err := loadABI.Pack("store", value)
+	pack, err := loadABI.Pack("store", uint64(1))
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	key, err := crypto.HexToECDSA(privateKey)
+	toAddress := common.HexToAddress(contractAddr)
+	return GasLimit(ethereum.CallMsg{From: from, To: &toAddress, Data: pack}, 210000)
+}
+
+// GenEstimateGasTx signs a store transaction of signer at its next nonce
+// with the given gas limit and fees.
+func GenEstimateGasTx(contractAddr string, signer Signer, gasLimit uint64, fees *Fees, value uint64) (*types.Transaction, error) {
+	loadABI, err := abi.JSON(strings.NewReader(ContractABI))
 	if err != nil {
 		return nil, err
 	}
-	chainID, err := client.NetworkID(context.Background())
+	pack, err := loadABI.Pack("store", value)
 	if err != nil {
 		return nil, err
 	}
 	toAddress := common.HexToAddress(contractAddr)
+	nonce, err := nonces.Peek(signer.Address())
+	if err != nil {
+		return nil, err
+	}
 
-	tx := types.NewTx(&types.LegacyTx{
-		To:    &toAddress,
-		Nonce: nonce,
-		Data:  pack,
-	})
-	signTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	tx := newTx(nonce, &toAddress, nil, gasLimit, pack, fees, storeAccessList(toAddress))
+	signTx, err := signer.SignTx(tx, chainID)
 	if err != nil {
 		return nil, err
 	}
 	return signTx, nil
 }
 
-func GenRawTx(contractAddr, privateKey string, value uint64) (*types.Transaction, error) {
+// GenRawTx signs a store transaction of key with the given nonce, gas limit
+// and fees without sending it.
+func GenRawTx(contractAddr string, key *ecdsa.PrivateKey, nonce, gasLimit uint64, fees *Fees, value uint64) (*types.Transaction, error) {
 	loadABI, err := abi.JSON(strings.NewReader(ContractABI))
 	if err != nil {
 		return nil, err
@@ -288,29 +330,10 @@ func GenRawTx(contractAddr, privateKey string, value uint64) (*types.Transaction
 	if err != nil {
 		return nil, err
 	}
-	key, err := crypto.HexToECDSA(privateKey)
-	if err != nil {
-		return nil, err
-	}
-	chainID, err := client.NetworkID(context.Background())
-	if err != nil {
-		return nil, err
-	}
-	gasLimit := uint64(210000)
-	gasPrice, err := client.SuggestGasPrice(context.Background())
-	if err != nil {
-		return nil, err
-	}
 	toAddress := common.HexToAddress(contractAddr)
 
-	tx := types.NewTx(&types.LegacyTx{
-		To:       &toAddress,
-		Nonce:    nonce,
-		Gas:      gasLimit,
-		GasPrice: gasPrice,
-		Data:     pack,
-	})
-	signTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), key)
+	tx := newTx(nonce, &toAddress, nil, gasLimit, pack, fees, storeAccessList(toAddress))
+	signTx, err := types.SignTx(tx, types.LatestSignerForChainID(chainID), key)
 	if err != nil {
 		return nil, err
 	}
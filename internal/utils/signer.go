@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs the transactions of a single sender.
+type Signer interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// KeySigner signs with a local private key.
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+// HexKeySigner returns the signer of a hex private key.
+func HexKeySigner(hex string) (*KeySigner, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewKeySigner(key), nil
+}
+
+// KeystoreSigner returns the signer of a Web3 Secret Storage keystore file.
+func KeystoreSigner(path, passphrase string) (*KeySigner, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := keystore.DecryptKey(bytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt keystore %s failed: %w", path, err)
+	}
+	return NewKeySigner(key.PrivateKey), nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) Key() *ecdsa.PrivateKey {
+	return s.key
+}
+
+func (s *KeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// ExternalSigner signs through an external signer speaking clef's
+// account_signTransaction JSON RPC API.
+type ExternalSigner struct {
+	signer  *external.ExternalSigner
+	account accounts.Account
+}
+
+// NewExternalSigner connects to the signer at endpoint, address selects the
+// signing account and defaults to the first account the signer lists.
+func NewExternalSigner(endpoint, address string) (*ExternalSigner, error) {
+	signer, err := external.NewExternalSigner(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("connect external signer %s failed: %w", endpoint, err)
+	}
+	list := signer.Accounts()
+	if address == "" {
+		if len(list) == 0 {
+			return nil, fmt.Errorf("external signer %s has no accounts", endpoint)
+		}
+		return &ExternalSigner{signer: signer, account: list[0]}, nil
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid address %s", address)
+	}
+	account := accounts.Account{Address: common.HexToAddress(address)}
+	if !signer.Contains(account) {
+		return nil, fmt.Errorf("external signer %s has no account %s", endpoint, address)
+	}
+	return &ExternalSigner{signer: signer, account: account}, nil
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *ExternalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signTx, err := s.signer.SignTx(s.account, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if signTx == nil {
+		return nil, fmt.Errorf("external signer returned no transaction")
+	}
+	// the signer may rewrite the transaction, make sure it is still ours
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signTx)
+	if err != nil {
+		return nil, err
+	}
+	if from != s.account.Address || signTx.Nonce() != tx.Nonce() {
+		return nil, fmt.Errorf("external signer returned a different transaction")
+	}
+	return signTx, nil
+}
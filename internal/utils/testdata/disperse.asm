@@ -0,0 +1,93 @@
+;; Disperse runtime, disperseEther(address[] recipients, uint256[] values)
+;; sends values[i] to recipients[i] and refunds the rest to the caller.
+;; Assemble with go-ethereum's core/asm (evm compile).
+
+    PUSH 0x00
+    CALLDATALOAD
+    PUSH 0xe0
+    SHR
+    PUSH 0xe63d38ed
+    EQ
+    JUMPI @disperse
+    JUMP @fail
+
+disperse:
+    ;; [rpos, vpos, n], positions of the array lengths
+    PUSH 0x04
+    CALLDATALOAD
+    PUSH 0x04
+    ADD
+    PUSH 0x24
+    CALLDATALOAD
+    PUSH 0x04
+    ADD
+    DUP2
+    CALLDATALOAD
+    DUP1
+    DUP3
+    CALLDATALOAD
+    EQ
+    ISZERO
+    JUMPI @fail
+    ;; [rpos, vpos, n, i]
+    PUSH 0x00
+
+loop:
+    DUP2
+    DUP2
+    LT
+    ISZERO
+    JUMPI @done
+    ;; [rpos, vpos, n, i, addr, value]
+    DUP1
+    PUSH 0x01
+    ADD
+    PUSH 0x05
+    SHL
+    DUP1
+    DUP6
+    ADD
+    CALLDATALOAD
+    SWAP1
+    DUP5
+    ADD
+    CALLDATALOAD
+    PUSH 0x00
+    DUP1
+    DUP1
+    DUP1
+    DUP5
+    DUP7
+    GAS
+    CALL
+    ISZERO
+    JUMPI @fail
+    POP
+    POP
+    PUSH 0x01
+    ADD
+    JUMP @loop
+
+done:
+    SELFBALANCE
+    DUP1
+    ISZERO
+    JUMPI @end
+    PUSH 0x00
+    DUP1
+    DUP1
+    DUP1
+    DUP5
+    CALLER
+    GAS
+    CALL
+    ISZERO
+    JUMPI @fail
+
+end:
+    STOP
+
+fail:
+    PUSH 0x00
+    DUP1
+    REVERT
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: GPL-3.0
+
+pragma solidity >=0.7.0 <0.9.0;
+
+/**
+ * @title Disperse
+ * @dev Send ether to many recipients in one transaction, DisperseBIN is
+ * assembled from the equivalent disperse.asm
+ */
+contract Disperse {
+
+    /**
+     * @dev Send values[i] to recipients[i], the rest is refunded
+     * @param recipients accounts to fund
+     * @param values wei sent to every recipient
+     */
+    function disperseEther(address[] calldata recipients, uint256[] calldata values) external payable {
+        require(recipients.length == values.length);
+        for (uint256 i = 0; i < recipients.length; i++) {
+            (bool ok, ) = recipients[i].call{value: values[i]}("");
+            require(ok);
+        }
+        uint256 balance = address(this).balance;
+        if (balance > 0) {
+            (bool ok, ) = msg.sender.call{value: balance}("");
+            require(ok);
+        }
+    }
+}